@@ -8,3 +8,11 @@ require (
 	github.com/orcaman/concurrent-map/v2 v2.0.1
 	github.com/ybbus/jsonrpc/v3 v3.1.5
 )
+
+require (
+	go.etcd.io/bbolt v1.3.10
+	golang.org/x/crypto v0.24.0
+	golang.org/x/time v0.5.0
+)
+
+require golang.org/x/sys v0.21.0 // indirect
@@ -2,56 +2,87 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/optclblast/blk/internal/controller/http"
-	"github.com/optclblast/blk/internal/infrastructure/getblock"
+	"github.com/optclblast/blk/internal/infrastructure/labels"
+	"github.com/optclblast/blk/internal/infrastructure/prices"
 	"github.com/optclblast/blk/internal/logger"
 	"github.com/optclblast/blk/internal/server"
 	"github.com/optclblast/blk/internal/usecase"
 )
 
-const (
-	// API access token
-	getblockAccessTokenEnv = "BLK_GETBLOCK_ACCESS_TOKEN"
-	// Log level
-	logLevelEnv = "BLK_LOG_LEVEL"
-	// Listen address
-	httpAddrEnv = "BLK_HTTP_ADDR"
-)
-
 // Init is a main function in our application lifecycle.
 // Init is responsible for bringing all the system's components together.
 func Init(ctx context.Context) error {
 	// Fetch env vars
-	getblockAccessToken := os.Getenv(getblockAccessTokenEnv)
-	logLevel := os.Getenv(logLevelEnv)
-	httpAddr := os.Getenv(httpAddrEnv)
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("error load config. %w", err)
+	}
 
 	// Build logger
 	log := logger.NewBuilder().
-		WithLevel(logger.MapLevel(logLevel)).
+		WithLevel(logger.MapLevel(cfg.logLevel)).
 		Build()
 
 	log.Info(
 		"starting blk server 0w0",
-		slog.String("address", httpAddr),
-		slog.String("log level", logLevel),
+		slog.String("address", cfg.httpAddr),
+		slog.String("log level", cfg.logLevel),
+		slog.String("accounting", cfg.accountingFlags),
 	)
 
 	// Initialize node provider client
-	getblockClient := getblock.NewClient(
-		log.WithGroup("getblock-client"),
-		getblockAccessToken,
-	)
+	nodeClient, closeNodeClient, err := newNodeClient(ctx, log, cfg)
+	if err != nil {
+		return fmt.Errorf("error init node client. %w", err)
+	}
 
 	// Initialize application layer
+	opts := []usecase.Option{
+		usecase.WithAccounting(cfg.accounting),
+		usecase.WithBatchSize(cfg.rpcBatchSize),
+		usecase.WithHeadFollower(cfg.followInterval),
+		usecase.WithAddressLists(cfg.include, cfg.exclude),
+	}
+
+	if len(cfg.labelsFiles) > 0 {
+		registry, err := labels.New(log.WithGroup("labels"), cfg.labelsFiles...)
+		if err != nil {
+			return fmt.Errorf("error load labels. %w", err)
+		}
+
+		go reloadOnHangup(ctx, log, registry)
+
+		opts = append(opts, usecase.WithLabels(registry))
+	}
+
+	switch {
+	case cfg.pricesFile != "":
+		source, err := prices.NewFileSource(cfg.pricesFile)
+		if err != nil {
+			return fmt.Errorf("error load prices. %w", err)
+		}
+
+		opts = append(opts, usecase.WithPrices(source))
+	case cfg.pricesURL != "":
+		opts = append(opts, usecase.WithPrices(prices.NewHTTPSource(cfg.pricesURL)))
+	}
+
 	ethInteractor := usecase.NewEthInteractor(
 		log.WithGroup("eth-interactor"),
-		getblockClient,
+		nodeClient,
+		opts...,
 	)
 
+	ethInteractor.Start(ctx)
+
 	// Initialize controller layer
 	walletsController := http.NewWalletsController(
 		log.WithGroup("wallets-controller"),
@@ -65,7 +96,7 @@ func Init(ctx context.Context) error {
 	)
 
 	// And run server with it
-	server := server.New(router, httpAddr)
+	server := server.New(router, cfg.httpAddr)
 
 	select {
 	case <-ctx.Done():
@@ -74,9 +105,24 @@ func Init(ctx context.Context) error {
 		log.Error("error listen to net ;_;", logger.Err(err))
 	}
 
-	if err := server.Shutdown(); err != nil {
-		return err
-	}
+	return errors.Join(server.Shutdown(), closeNodeClient())
+}
+
+// reloadOnHangup reloads the labels registry on every SIGHUP until ctx is done
+func reloadOnHangup(ctx context.Context, log *slog.Logger, registry *labels.Registry) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
 
-	return nil
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if err := registry.Reload(); err != nil {
+				log.Error("error reload labels", logger.Err(err))
+			}
+		}
+	}
 }
@@ -0,0 +1,191 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+	"github.com/optclblast/blk/internal/usecase"
+)
+
+const (
+	// API access token
+	getblockAccessTokenEnv = "BLK_GETBLOCK_ACCESS_TOKEN"
+	// Log level
+	logLevelEnv = "BLK_LOG_LEVEL"
+	// Listen address
+	httpAddrEnv = "BLK_HTTP_ADDR"
+	// Comma separated list of JSON rpc endpoints in priority order
+	rpcEndpointsEnv = "BLK_RPC_ENDPOINTS"
+	// Amount of blocks fetched with a single batch request
+	rpcBatchSizeEnv = "BLK_RPC_BATCH_SIZE"
+	// Requests per second allowed by the node provider plan. Applied to every provider
+	rpcRPSEnv = "BLK_RPC_RPS"
+	// Maximum amount of attempts per node call
+	rpcMaxAttemptsEnv = "BLK_RPC_MAX_ATTEMPTS"
+	// Comma separated list of accounting flags, e.g. "fees"
+	accountingEnv = "BLK_ACCOUNTING"
+	// Block cache file path. Cache is disabled if empty
+	cachePathEnv = "BLK_CACHE_PATH"
+	// Maximum amount of cached blocks
+	cacheMaxBlocksEnv = "BLK_CACHE_MAX_BLOCKS"
+	// Maximum size of cached blocks in bytes
+	cacheMaxBytesEnv = "BLK_CACHE_MAX_BYTES"
+	// Head follower polling interval, e.g. "4s". Follower is disabled if empty
+	followIntervalEnv = "BLK_FOLLOW_INTERVAL"
+	// File with addresses every result is limited to, one per line
+	includeFileEnv = "BLK_INCLUDE_FILE"
+	// File with addresses every result excludes, one per line
+	excludeFileEnv = "BLK_EXCLUDE_FILE"
+	// Comma separated list of CSV or JSON address labels files. Reloaded on SIGHUP
+	labelsFilesEnv = "BLK_LABELS_FILES"
+	// CSV file of historical USD prices. Exclusive with BLK_PRICES_URL
+	pricesFileEnv = "BLK_PRICES_FILE"
+	// Base URL of a USD price service. Exclusive with BLK_PRICES_FILE
+	pricesURLEnv = "BLK_PRICES_URL"
+)
+
+// config is the application configuration loaded from env vars
+type config struct {
+	getblockAccessToken string
+	logLevel            string
+	httpAddr            string
+	rpcEndpoints        []string
+	rpcBatchSize        int
+	rpcRPS              float64
+	rpcMaxAttempts      int
+	accountingFlags     string
+	accounting          usecase.Accounting
+	cachePath           string
+	cacheMaxBlocks      uint64
+	cacheMaxBytes       uint64
+	followInterval      time.Duration
+	include             []entities.Address
+	exclude             []entities.Address
+	labelsFiles         []string
+	pricesFile          string
+	pricesURL           string
+}
+
+// loadConfig fetches and parses env vars
+func loadConfig() (*config, error) {
+	cfg := &config{
+		getblockAccessToken: os.Getenv(getblockAccessTokenEnv),
+		logLevel:            os.Getenv(logLevelEnv),
+		httpAddr:            os.Getenv(httpAddrEnv),
+		accountingFlags:     os.Getenv(accountingEnv),
+		cachePath:           os.Getenv(cachePathEnv),
+		pricesFile:          os.Getenv(pricesFileEnv),
+		pricesURL:           os.Getenv(pricesURLEnv),
+	}
+
+	if cfg.pricesFile != "" && cfg.pricesURL != "" {
+		return nil, fmt.Errorf("error %s and %s can not be combined", pricesFileEnv, pricesURLEnv)
+	}
+
+	for _, endpoint := range strings.Split(os.Getenv(rpcEndpointsEnv), ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			cfg.rpcEndpoints = append(cfg.rpcEndpoints, endpoint)
+		}
+	}
+
+	for _, path := range strings.Split(os.Getenv(labelsFilesEnv), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			cfg.labelsFiles = append(cfg.labelsFiles, path)
+		}
+	}
+
+	var err error
+
+	if cfg.accounting, err = usecase.ParseAccounting(cfg.accountingFlags); err != nil {
+		return nil, fmt.Errorf("error parse accounting mode. %w", err)
+	}
+
+	if err = parseEnv(rpcBatchSizeEnv, &cfg.rpcBatchSize, strconv.Atoi); err != nil {
+		return nil, err
+	}
+
+	if err = parseEnv(rpcRPSEnv, &cfg.rpcRPS, parseFloat); err != nil {
+		return nil, err
+	}
+
+	if err = parseEnv(rpcMaxAttemptsEnv, &cfg.rpcMaxAttempts, strconv.Atoi); err != nil {
+		return nil, err
+	}
+
+	if err = parseEnv(cacheMaxBlocksEnv, &cfg.cacheMaxBlocks, parseUint); err != nil {
+		return nil, err
+	}
+
+	if err = parseEnv(cacheMaxBytesEnv, &cfg.cacheMaxBytes, parseUint); err != nil {
+		return nil, err
+	}
+
+	if err = parseEnv(followIntervalEnv, &cfg.followInterval, time.ParseDuration); err != nil {
+		return nil, err
+	}
+
+	if err = parseEnv(includeFileEnv, &cfg.include, loadAddressList); err != nil {
+		return nil, err
+	}
+
+	if err = parseEnv(excludeFileEnv, &cfg.exclude, loadAddressList); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// parseEnv parses an env var into dst if the var is set
+func parseEnv[T any](name string, dst *T, parse func(string) (T, error)) error {
+	v := os.Getenv(name)
+	if v == "" {
+		return nil
+	}
+
+	parsed, err := parse(v)
+	if err != nil {
+		return fmt.Errorf("error parse %s. %w", name, err)
+	}
+
+	*dst = parsed
+
+	return nil
+}
+
+func parseFloat(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+func parseUint(s string) (uint64, error) {
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// loadAddressList reads a file with one address per line.
+// Empty lines and lines starting with # are skipped
+func loadAddressList(path string) ([]entities.Address, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []entities.Address
+
+	for i, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		a, err := entities.ParseAddress(line)
+		if err != nil {
+			return nil, fmt.Errorf("error parse line %d. %w", i+1, err)
+		}
+
+		out = append(out, a)
+	}
+
+	return out, nil
+}
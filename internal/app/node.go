@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/optclblast/blk/internal/infrastructure/blockcache"
+	"github.com/optclblast/blk/internal/infrastructure/ethrpc"
+	"github.com/optclblast/blk/internal/infrastructure/failover"
+	"github.com/optclblast/blk/internal/infrastructure/getblock"
+	"github.com/optclblast/blk/internal/infrastructure/retry"
+	"github.com/optclblast/blk/internal/usecase"
+)
+
+// newNodeClient builds a node client stack: providers clients behind
+// a failover client, wrapped into retries and an optional block cache.
+// The returned close function releases the client resources
+func newNodeClient(
+	ctx context.Context,
+	log *slog.Logger,
+	cfg *config,
+) (usecase.NodeClient, func() error, error) {
+	var providers []failover.Provider
+
+	rateLimit := ethrpc.RateLimit(cfg.rpcRPS, int(math.Ceil(cfg.rpcRPS)))
+
+	if cfg.getblockAccessToken != "" {
+		providers = append(providers, failover.Provider{
+			Name: "getblock",
+			Client: getblock.NewClient(
+				log.WithGroup("getblock-client"),
+				cfg.getblockAccessToken,
+				rateLimit,
+			),
+		})
+	}
+
+	for _, endpoint := range cfg.rpcEndpoints {
+		client := ethrpc.NewClient(log.WithGroup("rpc-client"), endpoint, rateLimit)
+
+		providers = append(providers, failover.Provider{
+			Name:   client.String(),
+			Client: client,
+		})
+	}
+
+	if len(providers) == 0 {
+		return nil, nil, fmt.Errorf(
+			"error no node providers configured. set %s or %s",
+			getblockAccessTokenEnv,
+			rpcEndpointsEnv,
+		)
+	}
+
+	failoverClient := failover.New(log.WithGroup("failover-client"), providers)
+	failoverClient.Start(ctx)
+
+	// Retry transient errors of all the providers
+	var nodeClient usecase.NodeClient = retry.New(
+		log.WithGroup("retry-client"),
+		failoverClient,
+		retry.MaxAttempts(cfg.rpcMaxAttempts),
+	)
+
+	if cfg.cachePath == "" {
+		return nodeClient, func() error { return nil }, nil
+	}
+
+	// Serve final blocks from the local store
+	cache, err := blockcache.New(
+		log.WithGroup("block-cache"),
+		nodeClient,
+		cfg.cachePath,
+		blockcache.MaxBlocks(cfg.cacheMaxBlocks),
+		blockcache.MaxBytes(cfg.cacheMaxBytes),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cache, cache.Close, nil
+}
@@ -1,6 +1,287 @@
 package http
 
+import (
+	"math/big"
+
+	"github.com/optclblast/blk/internal/entities"
+	"github.com/optclblast/blk/internal/units"
+)
+
 // MostChangedWalletAddress response DTO object
 type MostChangedWalletAddressResponse struct {
+	// EIP-55 checksummed address with the highest balance delta.
+	// Kept for backward compatibility
+	Address   string            `json:"address"`
+	Addresses []*WalletDeltaDTO `json:"addresses"`
+	// Unit of the amounts values: wei, gwei or ether. Always wei in token mode
+	Unit string `json:"unit"`
+	// Total amount of fees burned in the window
+	Burned *AmountDTO `json:"burned"`
+	// Transactions totals by type name, e.g. blob. Omitted in token mode
+	TxTypes  map[string]*TxTypeStatsDTO `json:"tx_types,omitempty"`
+	Coverage *CoverageDTO               `json:"coverage"`
+}
+
+// TxTypeStatsDTO are totals of the transactions of a single type
+type TxTypeStatsDTO struct {
+	Transactions int        `json:"transactions"`
+	Value        *AmountDTO `json:"value"`
+	Tips         *AmountDTO `json:"tips"`
+	Burned       *AmountDTO `json:"burned"`
+}
+
+// AddressDelta response DTO object
+type AddressDeltaResponse struct {
+	*WalletDeltaDTO
+	// Unit of the amounts values: wei, gwei or ether. Always wei in token mode
+	Unit          string             `json:"unit"`
+	Contributions []*ContributionDTO `json:"contributions"`
+	Coverage      *CoverageDTO       `json:"coverage"`
+}
+
+// ContributionDTO is a single transfer changing an address balance
+type ContributionDTO struct {
+	// Omitted for withdrawals
+	TxHash      string `json:"tx_hash,omitempty"`
+	BlockNumber uint64 `json:"block_number"`
+	// Transfer kind: value, tip, burn, internal, reward or withdrawal
+	Kind string `json:"kind"`
+	// Omitted for burned fees, rewards and withdrawals
+	Counterparty string `json:"counterparty,omitempty"`
+	// in or out
+	Direction string     `json:"direction"`
+	Amount    *AmountDTO `json:"amount"`
+	// Address balance delta after the contribution
+	RunningTotal *AmountDTO `json:"running_total"`
+}
+
+// AmountDTO is an amount as exact decimal strings, e.g. 1250000000000000000 wei
+// is {"wei": "1250000000000000000", "value": "1.25"} in ether.
+// Token amounts are in token base units
+type AmountDTO struct {
+	Wei string `json:"wei"`
+	// The amount in the response unit
+	Value string `json:"value"`
+}
+
+// mapAmount maps a wei amount into its DTO representation with the value in the unit
+func mapAmount(wei *big.Int, unit units.Unit) *AmountDTO {
+	return &AmountDTO{
+		Wei:   units.Format(wei, units.UnitWei),
+		Value: units.Format(wei, unit),
+	}
+}
+
+// CoverageDTO describes which blocks of the requested window were processed
+type CoverageDTO struct {
+	FromBlock uint64   `json:"from_block"`
+	ToBlock   uint64   `json:"to_block"`
+	Requested int      `json:"requested"`
+	Processed int      `json:"processed"`
+	Missing   []uint64 `json:"missing"`
+}
+
+// mapDeltaReport maps a delta report into its DTO representation
+// with amounts in the unit
+func mapDeltaReport(report *entities.DeltaReport, unit units.Unit) MostChangedWalletAddressResponse {
+	resp := MostChangedWalletAddressResponse{
+		Addresses: mapWallets(report.Wallets, unit),
+		Unit:      string(unit),
+		Burned:    mapAmount(report.Burned, unit),
+		TxTypes:   mapTxTypes(report.TxTypes, unit),
+		Coverage:  mapCoverage(report.Coverage),
+	}
+
+	if len(report.Wallets) > 0 {
+		resp.Address = report.Wallets[0].Address.String()
+	}
+
+	return resp
+}
+
+// mapAddressDelta maps an address delta into its DTO representation
+// with amounts in the unit
+func mapAddressDelta(delta *entities.AddressDelta, unit units.Unit) AddressDeltaResponse {
+	resp := AddressDeltaResponse{
+		WalletDeltaDTO: mapWallets(entities.Wallets{delta.Wallet}, unit)[0],
+		Unit:           string(unit),
+		Contributions:  make([]*ContributionDTO, len(delta.Contributions)),
+		Coverage:       mapCoverage(delta.Coverage),
+	}
+
+	for i, c := range delta.Contributions {
+		dto := &ContributionDTO{
+			BlockNumber:  c.BlockNumber,
+			Kind:         c.Kind.String(),
+			Direction:    string(c.Direction),
+			Amount:       mapAmount(c.Amount, unit),
+			RunningTotal: mapAmount(c.Total, unit),
+		}
+
+		if !c.TxHash.IsZero() {
+			dto.TxHash = c.TxHash.Hex()
+		}
+
+		if c.Counterparty != nil {
+			dto.Counterparty = c.Counterparty.String()
+		}
+
+		resp.Contributions[i] = dto
+	}
+
+	return resp
+}
+
+// mapTxTypes maps a transactions breakdown by type into its DTO representation
+// with amounts in the unit
+func mapTxTypes(txTypes entities.TxTypes, unit units.Unit) map[string]*TxTypeStatsDTO {
+	if len(txTypes) == 0 {
+		return nil
+	}
+
+	out := make(map[string]*TxTypeStatsDTO, len(txTypes))
+
+	for tt, s := range txTypes {
+		out[tt.String()] = &TxTypeStatsDTO{
+			Transactions: s.Transactions,
+			Value:        mapAmount(s.Value, unit),
+			Tips:         mapAmount(s.Tips, unit),
+			Burned:       mapAmount(s.Burned, unit),
+		}
+	}
+
+	return out
+}
+
+// mapCoverage maps a window coverage into its DTO representation
+func mapCoverage(c *entities.Coverage) *CoverageDTO {
+	return &CoverageDTO{
+		FromBlock: c.FromBlock,
+		ToBlock:   c.ToBlock,
+		Requested: c.Requested,
+		Processed: len(c.Processed),
+		Missing:   c.Missing,
+	}
+}
+
+// WalletDeltaDTO is a wallet balance delta DTO object
+type WalletDeltaDTO struct {
+	// EIP-55 checksummed address
 	Address string `json:"address"`
+	// EIP-55 checksummed token contract address. Omitted for native ETH
+	Token    string     `json:"token,omitempty"`
+	Delta    *AmountDTO `json:"delta"`
+	AbsDelta *AmountDTO `json:"abs_delta"`
+	Inflow   *AmountDTO `json:"inflow"`
+	Outflow  *AmountDTO `json:"outflow"`
+	TxCount  int        `json:"tx_count"`
+	// The address is a contract created in the window
+	CreatedContract bool `json:"created_contract,omitempty"`
+	// Omitted if the address is not labeled
+	Label *LabelDTO `json:"label,omitempty"`
+	// Omitted if not requested
+	Verification *VerificationDTO `json:"verification,omitempty"`
+	// USD value of the delta rounded to cents, every contributing block
+	// priced at its timestamp. Omitted if no price source is configured
+	DeltaUSD string `json:"delta_usd,omitempty"`
+	// Not empty if the delta could not be valued
+	ValuationError string `json:"valuation_error,omitempty"`
+}
+
+// LabelDTO is a human readable address label
+type LabelDTO struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	// Token ticker symbol. Omitted if unknown
+	Symbol string `json:"symbol,omitempty"`
+}
+
+// mapLabel maps a label into its DTO representation
+func mapLabel(l *entities.Label) *LabelDTO {
+	if l == nil {
+		return nil
+	}
+
+	return &LabelDTO{Name: l.Name, Category: l.Category, Symbol: l.Symbol}
+}
+
+// VerificationDTO compares a computed delta with the on-chain balance change
+type VerificationDTO struct {
+	Matches     bool       `json:"matches"`
+	ActualDelta *AmountDTO `json:"actual_delta,omitempty"`
+	Discrepancy *AmountDTO `json:"discrepancy,omitempty"`
+	Hints       []string   `json:"hints,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// mapVerification maps a verification into its DTO representation
+// with amounts in the unit
+func mapVerification(v *entities.Verification, unit units.Unit) *VerificationDTO {
+	if v == nil {
+		return nil
+	}
+
+	out := &VerificationDTO{
+		Matches: v.Matches(),
+		Error:   v.Error,
+	}
+
+	if v.Error != "" {
+		return out
+	}
+
+	out.ActualDelta = mapAmount(v.Actual, unit)
+	out.Discrepancy = mapAmount(v.Discrepancy, unit)
+
+	for _, h := range v.Hints {
+		out.Hints = append(out.Hints, string(h))
+	}
+
+	return out
+}
+
+// mapWallets maps wallets into its DTO representation with amounts in the unit
+func mapWallets(wallets entities.Wallets, unit units.Unit) []*WalletDeltaDTO {
+	out := make([]*WalletDeltaDTO, len(wallets))
+
+	for i, w := range wallets {
+		out[i] = &WalletDeltaDTO{
+			Address:  w.Address.String(),
+			Delta:    mapAmount(w.Delta, unit),
+			AbsDelta: mapAmount(w.AbsDelta(), unit),
+			Inflow:   mapAmount(w.Inflow, unit),
+			Outflow:  mapAmount(w.Outflow, unit),
+			TxCount:  w.TxCount,
+
+			CreatedContract: w.CreatedContract,
+
+			Label: mapLabel(w.Label),
+
+			Verification: mapVerification(w.Verification, unit),
+		}
+
+		if !w.Token.IsZero() {
+			out[i].Token = w.Token.String()
+		}
+
+		if v := w.Valuation; v != nil {
+			out[i].DeltaUSD, out[i].ValuationError = formatUSD(v.DeltaUSD), v.Error
+		}
+	}
+
+	return out
+}
+
+// formatUSD returns the USD value rounded to cents. Nil value is empty
+func formatUSD(v *big.Rat) string {
+	if v == nil {
+		return ""
+	}
+
+	// Negative values rounded to zero are not signed
+	if s := v.FloatString(2); s != "-0.00" {
+		return s
+	}
+
+	return "0.00"
 }
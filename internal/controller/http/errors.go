@@ -4,7 +4,8 @@ import (
 	"errors"
 	"net/http"
 
-	"github.com/optclblast/blk/internal/infrastructure/getblock"
+	"github.com/optclblast/blk/internal/infrastructure/ethrpc"
+	"github.com/optclblast/blk/internal/usecase"
 )
 
 var (
@@ -31,13 +32,24 @@ func buildApiError(
 
 // mapError maps internal errors to its API representation
 func mapError(err error) apiError {
+	var missingErr *usecase.MissingBlocksError
+
 	switch {
+	case errors.As(err, &missingErr):
+		return buildApiError(http.StatusBadGateway, missingErr.Error())
 	case errors.Is(err, ErrorBadQueryParams):
 		return buildApiError(http.StatusBadRequest, "Invalid Query Params")
-	case errors.Is(err, getblock.ErrorRateLimitExceeded):
+	case errors.Is(err, usecase.ErrorInvalidRange), errors.Is(err, usecase.ErrorInvalidQuery):
+		return buildApiError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, ethrpc.ErrorRateLimitExceeded):
 		return buildApiError(
 			http.StatusTooManyRequests,
-			"GetBlock API rate limit exceeded! Type again later",
+			"Node provider API rate limit exceeded! Type again later",
+		)
+	case errors.Is(err, ethrpc.ErrorProviderUnavailable):
+		return buildApiError(
+			http.StatusServiceUnavailable,
+			"Node provider is unavailable! Type again later",
 		)
 	default:
 		return buildApiError(http.StatusInternalServerError, "Internal Server Error")
@@ -2,6 +2,7 @@ package http
 
 import (
 	"encoding/json"
+	"expvar"
 	"log/slog"
 	"net/http"
 
@@ -37,6 +38,14 @@ func NewRouter(
 		"most-changed",
 	))
 
+	r.Get("/addresses/{address}/delta", r.handle(
+		r.walletsController.AddressDelta,
+		"address-delta",
+	))
+
+	// Service metrics
+	r.Handle("/debug/vars", expvar.Handler())
+
 	return r
 }
 
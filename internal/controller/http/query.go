@@ -0,0 +1,171 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+	"github.com/optclblast/blk/internal/units"
+)
+
+// intParam returns a query parameter value as int. If the parameter is
+// missing or not positive, def is returned. Values above max are cut to max
+func intParam(query url.Values, name string, def, max int) (int, error) {
+	v := query.Get(name)
+	if v == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf(
+			"error invalid %s param value. %w",
+			name,
+			errors.Join(err, ErrorBadQueryParams),
+		)
+	}
+
+	if n > max {
+		n = max
+	}
+
+	if n <= 0 {
+		n = def
+	}
+
+	return n, nil
+}
+
+// boolParam returns a query parameter value as bool. If the parameter is
+// missing, false is returned
+func boolParam(query url.Values, name string) (bool, error) {
+	v := query.Get(name)
+	if v == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf(
+			"error invalid %s param value. %w",
+			name,
+			errors.Join(err, ErrorBadQueryParams),
+		)
+	}
+
+	return b, nil
+}
+
+// timeParam returns a query parameter value as time. Both RFC 3339 and
+// unix seconds are accepted. If the parameter is missing, zero time is returned
+func timeParam(query url.Values, name string) (time.Time, error) {
+	v := query.Get(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+
+	ts, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"error invalid %s param value. %w",
+			name,
+			errors.Join(err, ErrorBadQueryParams),
+		)
+	}
+
+	return ts, nil
+}
+
+// addressParam returns a query parameter value as an address of any letter case.
+// If the parameter is missing, the zero address is returned
+func addressParam(query url.Values, name string) (entities.Address, error) {
+	v := query.Get(name)
+	if v == "" {
+		return entities.Address{}, nil
+	}
+
+	a, err := entities.ParseAddress(v)
+	if err != nil {
+		return entities.Address{}, fmt.Errorf(
+			"error invalid %s param value. %w",
+			name,
+			errors.Join(err, ErrorBadQueryParams),
+		)
+	}
+
+	return a, nil
+}
+
+// addressListParam returns a query parameter value as a list of comma separated
+// addresses of any letter case. If the parameter is missing, nil is returned
+func addressListParam(query url.Values, name string, max int) ([]entities.Address, error) {
+	v := query.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(v, ",")
+	if len(parts) > max {
+		return nil, fmt.Errorf("error too many %s param values. %w", name, ErrorBadQueryParams)
+	}
+
+	out := make([]entities.Address, 0, len(parts))
+
+	for _, p := range parts {
+		a, err := entities.ParseAddress(strings.TrimSpace(p))
+		if err != nil {
+			return nil, fmt.Errorf("error invalid %s param value. %w", name, errors.Join(err, ErrorBadQueryParams))
+		}
+
+		out = append(out, a)
+	}
+
+	return out, nil
+}
+
+// unitParam returns a query parameter value as an amounts unit.
+// If the parameter is missing, units.UnitWei is returned
+func unitParam(query url.Values, name string) (units.Unit, error) {
+	u, err := units.ParseUnit(query.Get(name))
+	if err != nil {
+		return "", fmt.Errorf(
+			"error invalid %s param value. %w",
+			name,
+			errors.Join(err, ErrorBadQueryParams),
+		)
+	}
+
+	return u, nil
+}
+
+// listParam returns a query parameter value as a list of comma separated
+// lower case values. If the parameter is missing, nil is returned
+func listParam(query url.Values, name string, max int) ([]string, error) {
+	v := query.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(v, ",")
+	if len(parts) > max {
+		return nil, fmt.Errorf("error too many %s param values. %w", name, ErrorBadQueryParams)
+	}
+
+	out := make([]string, 0, len(parts))
+
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, strings.ToLower(p))
+		}
+	}
+
+	return out, nil
+}
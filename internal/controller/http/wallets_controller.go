@@ -6,68 +6,198 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
-	"strconv"
+	"net/url"
 	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/optclblast/blk/internal/entities"
+	"github.com/optclblast/blk/internal/units"
 	"github.com/optclblast/blk/internal/usecase"
 )
 
 type WalletsController interface {
-	// MostChangedWalletAddress returns the address of the wallet whose balance
-	// delta was the highest among other wallets participating in transactions
-	// from numBlocks blocks to the HEAD block.
+	// MostChangedWalletAddress returns the addresses of the wallets whose balance
+	// deltas were the highest among other wallets participating in transactions
+	// in the requested blocks window.
 	MostChangedWalletAddress(w http.ResponseWriter, r *http.Request) (any, error)
+
+	// AddressDelta returns the address balance delta in the requested blocks
+	// window along with every contributing transfer and the running total.
+	AddressDelta(w http.ResponseWriter, r *http.Request) (any, error)
 }
 
 const (
 	defaultNumBlocks = 100
-	maxNumBlocks     = 150
+	defaultLimit     = 1
+	maxLimit         = 100
+	maxListAddresses = 1000
+
+	maxListCategories = 32
+
+	// tokenAll token param value selects ERC-20 transfers of all the tokens
+	tokenAll = "all"
 )
 
-// MostChangedWalletAddress returns the address of the wallet whose balance
-// delta was the highest among other wallets participating in transactions
-// from numBlocks blocks to the HEAD block.
+// MostChangedWalletAddress returns the addresses of the wallets whose balance
+// deltas were the highest among other wallets participating in transactions
+// in the requested blocks window.
 func (c *walletsController) MostChangedWalletAddress(
 	w http.ResponseWriter,
 	r *http.Request,
 ) (any, error) {
 	defer r.Body.Close()
 
+	q, err := parseMostChangedQuery(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	unit, err := parseUnit(r.URL.Query(), q)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	report, err := c.usecase.TopChangedAddresses(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("error fetch the most changed wallets. %w", err)
+	}
+
+	return mapDeltaReport(report, unit), nil
+}
+
+// AddressDelta returns the address balance delta in the requested blocks
+// window along with every contributing transfer and the running total.
+func (c *walletsController) AddressDelta(
+	w http.ResponseWriter,
+	r *http.Request,
+) (any, error) {
+	defer r.Body.Close()
+
+	address, err := entities.ParseAddress(chi.URLParam(r, "address"))
+	if err != nil {
+		return nil, fmt.Errorf("error invalid address. %w", errors.Join(err, ErrorBadQueryParams))
+	}
+
+	q, err := parseWindowQuery(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	if q.AllTokens {
+		return nil, fmt.Errorf("error token must be a contract address. %w", ErrorBadQueryParams)
+	}
+
+	unit, err := parseUnit(r.URL.Query(), q)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	delta, err := c.usecase.AddressDelta(ctx, address, q)
+	if err != nil {
+		return nil, fmt.Errorf("error explain the address delta. %w", err)
+	}
+
+	return mapAddressDelta(delta, unit), nil
+}
+
+// parseMostChangedQuery builds a most changed addresses query from query parameters
+func parseMostChangedQuery(query url.Values) (usecase.Query, error) {
+	q, err := parseWindowQuery(query)
+	if err != nil {
+		return q, err
+	}
+
+	if q.Limit, err = intParam(query, "limit", defaultLimit, maxLimit); err != nil {
+		return q, err
+	}
+
+	if q.Include, err = addressListParam(query, "include", maxListAddresses); err != nil {
+		return q, err
+	}
+
+	if q.Exclude, err = addressListParam(query, "exclude", maxListAddresses); err != nil {
+		return q, err
+	}
+
+	if q.ExcludeCategories, err = listParam(query, "exclude_category", maxListCategories); err != nil {
+		return q, err
+	}
+
+	if q.Kind, err = usecase.ParseAddressKind(query.Get("kind")); err != nil {
+		return q, errors.Join(err, ErrorBadQueryParams)
+	}
+
+	if q.Rank, err = entities.ParseRanking(query.Get("rank")); err != nil {
+		return q, errors.Join(err, ErrorBadQueryParams)
+	}
+
+	if q.Verify, err = boolParam(query, "verify"); err != nil {
+		return q, err
+	}
+
+	return q, nil
+}
+
+// parseWindowQuery builds a query of the blocks window, strict mode
+// and token from query parameters
+func parseWindowQuery(query url.Values) (usecase.Query, error) {
 	var (
-		query     = r.URL.Query()
-		numBlocks = defaultNumBlocks
-		err       error
+		q   usecase.Query
+		err error
 	)
 
-	if v, ok := query["blocks"]; ok && len(v) > 0 {
-		numBlocks, err = strconv.Atoi(v[0])
-		if err != nil {
-			return nil, fmt.Errorf(
-				"error invalid block param value. %w",
-				errors.Join(err, ErrorBadQueryParams),
-			)
-		}
+	if q.NumBlocks, err = intParam(query, "blocks", defaultNumBlocks, usecase.DefaultMaxWindow); err != nil {
+		return q, err
+	}
 
-		if numBlocks > maxNumBlocks {
-			numBlocks = maxNumBlocks
-		}
+	q.FromBlock = query.Get("from_block")
+	q.ToBlock = query.Get("to_block")
+
+	if q.FromTime, err = timeParam(query, "from_time"); err != nil {
+		return q, err
+	}
+
+	if q.ToTime, err = timeParam(query, "to_time"); err != nil {
+		return q, err
+	}
 
-		if numBlocks <= 0 {
-			numBlocks = defaultNumBlocks
+	if q.Strict, err = boolParam(query, "strict"); err != nil {
+		return q, err
+	}
+
+	if q.AllTokens = query.Get("token") == tokenAll; !q.AllTokens {
+		if q.Token, err = addressParam(query, "token"); err != nil {
+			return q, err
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
-	defer cancel()
+	return q, nil
+}
 
-	walletAddress, err := c.usecase.MostChangedAddress(ctx, numBlocks)
+// parseUnit returns the amounts unit of the query, units.UnitEther by default.
+// Token amounts are in token base units, so only wei is accepted in token mode
+func parseUnit(query url.Values, q usecase.Query) (units.Unit, error) {
+	tokens := q.AllTokens || !q.Token.IsZero()
+	if query.Get("unit") == "" && !tokens {
+		return units.UnitEther, nil
+	}
+
+	unit, err := unitParam(query, "unit")
 	if err != nil {
-		return nil, fmt.Errorf("error fetch the most changed wallet. %w", err)
+		return "", err
+	}
+
+	if unit != units.UnitWei && tokens {
+		return "", fmt.Errorf("error unit is not supported in token mode. %w", ErrorBadQueryParams)
 	}
 
-	return MostChangedWalletAddressResponse{
-		Address: walletAddress,
-	}, nil
+	return unit, nil
 }
 
 // walletsController interface implementation
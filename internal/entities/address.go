@@ -0,0 +1,157 @@
+package entities
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// AddressLength is the length of an address in bytes
+	AddressLength = 20
+	// HashLength is the length of a hash in bytes
+	HashLength = 32
+)
+
+var (
+	// ErrorInvalidAddress is thrown when a value is not a 0x prefixed 20 bytes hex string
+	ErrorInvalidAddress = errors.New("invalid address")
+
+	// ErrorInvalidHash is thrown when a value is not a 0x prefixed 32 bytes hex string
+	ErrorInvalidHash = errors.New("invalid hash")
+)
+
+// Address is a 20 bytes account address. Addresses are compared by value,
+// so the same address parsed from different letter cases is equal
+type Address [AddressLength]byte
+
+// ParseAddress parses a 0x prefixed 20 bytes hex address of any letter case
+func ParseAddress(s string) (Address, error) {
+	var a Address
+
+	if err := decodeHex(a[:], s); err != nil {
+		return Address{}, fmt.Errorf("%w %q", ErrorInvalidAddress, s)
+	}
+
+	return a, nil
+}
+
+// Hex returns the lower case 0x prefixed hex address
+func (a Address) Hex() string {
+	return "0x" + hex.EncodeToString(a[:])
+}
+
+// String returns the EIP-55 checksummed hex address
+func (a Address) String() string {
+	buf := []byte(a.Hex())
+
+	h := sha3.NewLegacyKeccak256()
+	h.Write(buf[2:])
+	sum := h.Sum(nil)
+
+	// A letter is upper case if the matching nibble of the hash of
+	// the lower case hex address is 8 or greater
+	for i := 2; i < len(buf); i++ {
+		nibble := sum[(i-2)/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+
+		if buf[i] >= 'a' && nibble&0xf >= 8 {
+			buf[i] -= 'a' - 'A'
+		}
+	}
+
+	return string(buf)
+}
+
+// IsZero reports whether the address is the zero address
+func (a Address) IsZero() bool {
+	return a == Address{}
+}
+
+// MarshalText encodes the address as an EIP-55 checksummed hex string
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText decodes a 0x prefixed 20 bytes hex address
+func (a *Address) UnmarshalText(text []byte) error {
+	parsed, err := ParseAddress(string(text))
+	if err != nil {
+		return err
+	}
+
+	*a = parsed
+
+	return nil
+}
+
+// Hash is a 32 bytes keccak256 hash, e.g. a block or a transaction hash
+type Hash [HashLength]byte
+
+// ParseHash parses a 0x prefixed 32 bytes hex hash of any letter case
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+
+	if err := decodeHex(h[:], s); err != nil {
+		return Hash{}, fmt.Errorf("%w %q", ErrorInvalidHash, s)
+	}
+
+	return h, nil
+}
+
+// mustParseHash parses a hash constant. Panics if s is not a valid hash
+func mustParseHash(s string) Hash {
+	h, err := ParseHash(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return h
+}
+
+// Hex returns the lower case 0x prefixed hex hash
+func (h Hash) Hex() string {
+	return "0x" + hex.EncodeToString(h[:])
+}
+
+// String returns the lower case 0x prefixed hex hash
+func (h Hash) String() string {
+	return h.Hex()
+}
+
+// IsZero reports whether all the hash bytes are zero
+func (h Hash) IsZero() bool {
+	return h == Hash{}
+}
+
+// MarshalText encodes the hash as a lower case hex string
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.Hex()), nil
+}
+
+// UnmarshalText decodes a 0x prefixed 32 bytes hex hash
+func (h *Hash) UnmarshalText(text []byte) error {
+	parsed, err := ParseHash(string(text))
+	if err != nil {
+		return err
+	}
+
+	*h = parsed
+
+	return nil
+}
+
+// decodeHex decodes a 0x prefixed hex string of exactly len(dst) bytes into dst
+func decodeHex(dst []byte, s string) error {
+	if len(s) != 2+2*len(dst) || (s[:2] != "0x" && s[:2] != "0X") {
+		return errors.New("invalid length or prefix")
+	}
+
+	_, err := hex.Decode(dst, []byte(s[2:]))
+
+	return err
+}
@@ -0,0 +1,123 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	for _, tc := range addressTests {
+		t.Run(tc.Title, func(t *testing.T) {
+			a, err := ParseAddress(tc.Input)
+
+			if tc.MustFail {
+				if !errors.Is(err, ErrorInvalidAddress) {
+					t.Fatalf("invalid error: %v | Expected: %s", err, ErrorInvalidAddress)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if a.String() != tc.Expected {
+				t.Fatalf("invalid checksum address: %s | Expected: %s", a, tc.Expected)
+			}
+		})
+	}
+}
+
+type AddressTestCase struct {
+	Title    string
+	Input    string
+	Expected string
+	MustFail bool
+}
+
+var addressTests = []AddressTestCase{
+	{
+		Title:    "Lower case address",
+		Input:    "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		Expected: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+	},
+	{
+		Title:    "Upper case address",
+		Input:    "0xFB6916095CA1DF60BB79CE92CE3EA74C37C5D359",
+		Expected: "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+	},
+	{
+		Title:    "Checksummed address",
+		Input:    "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		Expected: "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+	},
+	{
+		Title:    "Mixed case address",
+		Input:    "0xd1220a0CF47c7B9Be7A2E6BA89F429762e7b9aDb",
+		Expected: "0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	},
+	{
+		Title:    "No 0x prefix",
+		Input:    "5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		MustFail: true,
+	},
+	{
+		Title:    "Too short",
+		Input:    "0x5aaeb6053f3e94c9b9a09f33669435e7ef1bea",
+		MustFail: true,
+	},
+	{
+		Title:    "Not a hex string",
+		Input:    "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beazz",
+		MustFail: true,
+	},
+}
+
+func TestAddressJSON(t *testing.T) {
+	var decoded struct {
+		Address Address  `json:"address"`
+		To      *Address `json:"to"`
+		Hash    Hash     `json:"hash"`
+	}
+
+	data := `{
+		"address": "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		"to": null,
+		"hash": "0x00000000000000000000000000000000000000000000000000000000000000B1"
+	}`
+
+	if err := json.Unmarshal([]byte(data), &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.To != nil || decoded.Hash != (Hash{31: 0xb1}) {
+		t.Fatalf("invalid decoded object: %+v", decoded)
+	}
+
+	out, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"address":"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed","to":null,` +
+		`"hash":"0x00000000000000000000000000000000000000000000000000000000000000b1"}`
+
+	if string(out) != expected {
+		t.Fatalf("invalid encoded object: %s | Expected: %s", out, expected)
+	}
+
+	// Addresses of any letter case are equal
+	if err := json.Unmarshal([]byte(`{"address": "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED"}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Address.Hex() != "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed" {
+		t.Fatalf("invalid decoded address: %s", decoded.Address.Hex())
+	}
+
+	if err := json.Unmarshal([]byte(`{"hash": "0xb1"}`), &decoded); !errors.Is(err, ErrorInvalidHash) {
+		t.Fatalf("invalid error: %v | Expected: %s", err, ErrorInvalidHash)
+	}
+}
@@ -0,0 +1,80 @@
+package entities
+
+import "math/big"
+
+// Direction is a direction of funds relative to an address
+type Direction string
+
+const (
+	DirectionIn  Direction = "in"
+	DirectionOut Direction = "out"
+)
+
+// Contribution is a single transfer changing an address balance
+type Contribution struct {
+	TxHash      Hash
+	BlockNumber uint64
+	Kind        TransferKind
+	// The other side of the transfer. Nil for burned fees, rewards and withdrawals
+	Counterparty *Address
+	Direction    Direction
+	// Transferred amount. Always positive
+	Amount *big.Int
+	// Address balance delta after the contribution
+	Total *big.Int
+}
+
+// AddressDelta is an address balance delta explained by its contributions
+type AddressDelta struct {
+	Wallet *Wallet
+	// Contributions ordered by block number, then by their order within the block
+	Contributions []*Contribution
+	// Blocks the delta is computed from
+	Coverage *Coverage
+}
+
+// NewAddressDelta returns an empty AddressDelta of the address
+func NewAddressDelta(address, token Address) *AddressDelta {
+	w := NewWallet(address)
+	w.Token = token
+
+	return &AddressDelta{Wallet: w}
+}
+
+// Apply applies the transfer if it changes the address balance. Transfers
+// are applied to the wallet exactly as the deltas aggregation does
+func (d *AddressDelta) Apply(t *Transfer) {
+	address := d.Wallet.Address
+
+	if t.HasSender() && t.From == address {
+		d.Wallet.ApplySent(t)
+		d.add(t, &t.To, DirectionOut)
+	}
+
+	if t.HasRecipient() && t.To == address {
+		d.Wallet.ApplyReceived(t)
+		var from *Address
+		if t.HasSender() {
+			from = &t.From
+		}
+
+		d.add(t, from, DirectionIn)
+	}
+}
+
+// add appends a contribution of the transfer
+func (d *AddressDelta) add(t *Transfer, counterparty *Address, dir Direction) {
+	if t.Kind == TransferKindBurn {
+		counterparty = nil
+	}
+
+	d.Contributions = append(d.Contributions, &Contribution{
+		TxHash:       t.TxHash,
+		BlockNumber:  t.BlockNumber,
+		Kind:         t.Kind,
+		Counterparty: counterparty,
+		Direction:    dir,
+		Amount:       new(big.Int).Set(t.Value),
+		Total:        new(big.Int).Set(d.Wallet.Delta),
+	})
+}
@@ -2,9 +2,12 @@ package entities
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
+	"math/big"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestBlockUnmarshal(t *testing.T) {
@@ -20,9 +23,11 @@ func TestBlockUnmarshal(t *testing.T) {
 				t.Fatal(err)
 			}
 
-			block := new(Block)
+			var envelope struct {
+				Result *Block `json:"result"`
+			}
 
-			err = json.Unmarshal(data, block)
+			err = json.Unmarshal(data, &envelope)
 
 			if tc.MustFail && err == nil {
 				t.Fatal("unmarshall must fail")
@@ -35,6 +40,78 @@ func TestBlockUnmarshal(t *testing.T) {
 	}
 }
 
+func TestBlockMarshalRoundTrip(t *testing.T) {
+	data, err := os.ReadFile("./test_data/test.block.valid.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var envelope struct {
+		Result *Block `json:"result"`
+	}
+
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		t.Fatal(err)
+	}
+
+	block := envelope.Result
+
+	out, err := json.Marshal(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := new(Block)
+
+	if err := json.Unmarshal(out, decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Hash != block.Hash ||
+		decoded.Number.Cmp(block.Number) != 0 ||
+		!decoded.Timestamp.Equal(block.Timestamp) ||
+		len(decoded.Transactions) != len(block.Transactions) {
+		t.Fatalf("invalid decoded block: %s %s | Expected: %s %s", decoded.Hash, decoded.Number, block.Hash, block.Number)
+	}
+
+	for i, tx := range block.Transactions {
+		d := decoded.Transactions[i]
+
+		if d.Hash != tx.Hash || d.Value.Cmp(tx.Value) != 0 || d.From != tx.From || !sameRecipient(d.To, tx.To) {
+			t.Fatalf("invalid decoded tx #%d: %s | Expected: %s", i, d.Hash, tx.Hash)
+		}
+	}
+
+	if len(block.Withdrawals) != 16 || len(decoded.Withdrawals) != 16 {
+		t.Fatalf("invalid withdrawals: %d, %d decoded | Expected: 16", len(block.Withdrawals), len(decoded.Withdrawals))
+	}
+
+	// 0x1117858 gwei
+	if w := decoded.Withdrawals[0]; w.Address != mustParseAddress("0x680e6cebc672f310123696b93be888e4dd2745c5") ||
+		w.AmountWei().String() != "17922136000000000" {
+		t.Fatalf("invalid decoded withdrawal: %s %s", w.Address, w.AmountWei())
+	}
+}
+
+// sameRecipient reports whether both transactions have the same recipient
+func sameRecipient(a, b *Address) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+// mustParseAddress parses a test address. Panics if s is not a valid address
+func mustParseAddress(s string) Address {
+	a, err := ParseAddress(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return a
+}
+
 type TestCase struct {
 	Title    string
 	Path     string
@@ -60,3 +137,285 @@ var tests = []TestCase{
 		MustFail: true,
 	},
 }
+
+func TestDecodeErrors(t *testing.T) {
+	for _, tc := range decodeTests {
+		t.Run(tc.Title, func(t *testing.T) {
+			err := json.Unmarshal([]byte(tc.Data), tc.Object)
+
+			if tc.ExpectedErr == nil {
+				if err != nil {
+					t.Fatalf("error: %s\n", err.Error())
+				}
+
+				return
+			}
+
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) || !errors.Is(err, tc.ExpectedErr) {
+				t.Fatalf("invalid error: %v | Expected: %s", err, tc.ExpectedErr)
+			}
+
+			if decodeErr.Field != tc.ExpectedField ||
+				decodeErr.Block != tc.ExpectedBlock ||
+				decodeErr.Tx != tc.ExpectedTx {
+				t.Fatalf(
+					"invalid error: %s %s %s | Expected: %s %s %s",
+					decodeErr.Field, decodeErr.Block, decodeErr.Tx,
+					tc.ExpectedField, tc.ExpectedBlock, tc.ExpectedTx,
+				)
+			}
+		})
+	}
+}
+
+type DecodeTestCase struct {
+	Title         string
+	Object        any
+	Data          string
+	ExpectedErr   error
+	ExpectedField string
+	ExpectedBlock string
+	ExpectedTx    string
+}
+
+const (
+	testBlockHash = "0x00000000000000000000000000000000000000000000000000000000000000b1"
+	testTxHash    = "0x00000000000000000000000000000000000000000000000000000000000000a1"
+
+	validBlockJSON = `{"hash": "` + testBlockHash + `", "number": "0x10", "gasLimit": "0x1c9c380", "gasUsed": "0x0",
+		"timestamp": "0x6656b3a3", "transactions": []}`
+	validTxJSON = `{"hash": "` + testTxHash + `", "blockNumber": "0x10", "gas": "0x5208", "nonce": "0x0", "value": "0x1"}`
+)
+
+var decodeTests = []DecodeTestCase{
+	{
+		Title:  "Valid block without optional quantities",
+		Object: new(Block),
+		Data:   validBlockJSON,
+	},
+	{
+		Title:         "Block number is missing",
+		Object:        new(Block),
+		Data:          `{"hash": "` + testBlockHash + `", "gasLimit": "0x1", "gasUsed": "0x0", "timestamp": "0x1"}`,
+		ExpectedErr:   ErrorMissingField,
+		ExpectedField: "number",
+		ExpectedBlock: testBlockHash,
+	},
+	{
+		Title:         "Block quantity has no 0x prefix",
+		Object:        new(Block),
+		Data:          `{"number": "0x10", "gasLimit": "1c9c380", "gasUsed": "0x0", "timestamp": "0x1"}`,
+		ExpectedErr:   ErrorInvalidQuantity,
+		ExpectedField: "gasLimit",
+		ExpectedBlock: "0x10",
+	},
+	{
+		Title:         "Block quantity is empty",
+		Object:        new(Block),
+		Data:          `{"number": "0x10", "gasLimit": "0x", "gasUsed": "0x0", "timestamp": "0x1"}`,
+		ExpectedErr:   ErrorInvalidQuantity,
+		ExpectedField: "gasLimit",
+		ExpectedBlock: "0x10",
+	},
+	{
+		Title:  "Valid transaction",
+		Object: new(Transaction),
+		Data:   validTxJSON,
+	},
+	{
+		Title:         "Transaction value is malformed",
+		Object:        new(Transaction),
+		Data:          `{"hash": "` + testTxHash + `", "blockNumber": "0x10", "gas": "0x5208", "nonce": "0x0", "value": "0xzz"}`,
+		ExpectedErr:   ErrorInvalidQuantity,
+		ExpectedField: "value",
+		ExpectedBlock: "0x10",
+		ExpectedTx:    testTxHash,
+	},
+	{
+		Title:         "Transaction value is negative",
+		Object:        new(Transaction),
+		Data:          `{"hash": "` + testTxHash + `", "blockNumber": "0x10", "gas": "0x5208", "nonce": "0x0", "value": "0x-1"}`,
+		ExpectedErr:   ErrorInvalidQuantity,
+		ExpectedField: "value",
+		ExpectedBlock: "0x10",
+		ExpectedTx:    testTxHash,
+	},
+	{
+		Title:         "Transaction of a block is invalid",
+		Object:        new(Block),
+		Data:          `{"hash": "` + testBlockHash + `", "transactions": [{"hash": "` + testTxHash + `", "blockNumber": "0x10"}]}`,
+		ExpectedErr:   ErrorMissingField,
+		ExpectedField: "gas",
+		ExpectedBlock: "0x10",
+		ExpectedTx:    testTxHash,
+	},
+	{
+		Title:         "Withdrawal amount is missing",
+		Object:        new(Withdrawal),
+		Data:          `{"index": "0x1", "validatorIndex": "0x2", "address": "0x000000000000000000000000000000000000000a"}`,
+		ExpectedErr:   ErrorMissingField,
+		ExpectedField: "amount",
+	},
+}
+
+func TestTypedTransactionUnmarshal(t *testing.T) {
+	data := `[
+		{"hash": "0x00000000000000000000000000000000000000000000000000000000000000a0", "gas": "0x5208", "nonce": "0x0", "value": "0x1", "v": "0x25"},
+		{
+			"hash": "0x00000000000000000000000000000000000000000000000000000000000000a3", "type": "0x3", "gas": "0x5208", "nonce": "0x1", "value": "0x0",
+			"yParity": "0x1", "maxFeePerBlobGas": "0x3b9aca00",
+			"accessList": [{"address": "0x000000000000000000000000000000000000000a", "storageKeys": ["0x0000000000000000000000000000000000000000000000000000000000000001"]}],
+			"blobVersionedHashes": [
+				"0x01000000000000000000000000000000000000000000000000000000000000b1",
+				"0x01000000000000000000000000000000000000000000000000000000000000b2"
+			]
+		},
+		{
+			"hash": "0x00000000000000000000000000000000000000000000000000000000000000a4", "type": "0x4", "gas": "0x5208", "nonce": "0x2", "value": "0x0", "yParity": "0x0",
+			"authorizationList": [
+				{"chainId": "0x1", "address": "0x000000000000000000000000000000000000000d", "nonce": "0x7", "yParity": "0x1", "r": "0x1", "s": "0x2"}
+			]
+		}
+	]`
+
+	var txs []*Transaction
+
+	if err := json.Unmarshal([]byte(data), &txs); err != nil {
+		t.Fatal(err)
+	}
+
+	if txs[0].Type != TxTypeLegacy || txs[0].YParity != nil {
+		t.Fatalf("invalid legacy tx: %s %v", txs[0].Type, txs[0].YParity)
+	}
+
+	blob := txs[1]
+	if blob.Type != TxTypeBlob ||
+		blob.YParity.Int64() != 1 ||
+		blob.MaxFeePerBlobGas.Int64() != 1_000_000_000 ||
+		len(blob.BlobVersionedHashes) != 2 ||
+		len(blob.AccessList) != 1 || blob.AccessList[0].StorageKeys[0] != (Hash{31: 1}) {
+		t.Fatalf("invalid blob tx: %+v", blob)
+	}
+
+	setCode := txs[2]
+	if setCode.Type != TxTypeSetCode ||
+		len(setCode.AuthorizationList) != 1 ||
+		setCode.AuthorizationList[0].Address != (Address{19: 0xd}) ||
+		setCode.AuthorizationList[0].Nonce.Int64() != 7 {
+		t.Fatalf("invalid set code tx: %+v", setCode)
+	}
+
+	// Typed fields survive a round trip
+	out, err := json.Marshal(txs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded []*Transaction
+
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tx := range decoded {
+		if tx.Type != txs[i].Type || len(tx.AuthorizationList) != len(txs[i].AuthorizationList) {
+			t.Fatalf("invalid decoded tx #%d: %s | Expected: %s", i, tx.Type, txs[i].Type)
+		}
+	}
+}
+
+func TestERC20Transfer(t *testing.T) {
+	for _, tc := range logTests {
+		t.Run(tc.Title, func(t *testing.T) {
+			l := new(Log)
+
+			if err := json.Unmarshal([]byte(tc.Log), l); err != nil {
+				t.Fatal(err)
+			}
+
+			tr, ok := l.ERC20Transfer()
+			if ok != (tc.Expected != nil) {
+				t.Fatalf("invalid decode result: %v | Expected: %v", ok, tc.Expected != nil)
+			}
+
+			if !ok {
+				return
+			}
+
+			if tr.From != tc.Expected.From ||
+				tr.To != tc.Expected.To ||
+				tr.Token != tc.Expected.Token ||
+				tr.Value.Cmp(tc.Expected.Value) != 0 ||
+				!tr.BlockTime.Equal(tc.Expected.BlockTime) {
+				t.Fatalf("invalid transfer: %+v | Expected: %+v", tr, tc.Expected)
+			}
+		})
+	}
+}
+
+type LogTestCase struct {
+	Title    string
+	Log      string
+	Expected *Transfer
+}
+
+var logTests = []LogTestCase{
+	{
+		Title: "ERC-20 transfer",
+		Log: `{
+			"address": "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+			"topics": [
+				"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+				"0x000000000000000000000000a9d1e08c7793af67e9d92fe308d5697fb81d3e43",
+				"0x00000000000000000000000077696bb39917c91a0c3908d577d5e322095425ca"
+			],
+			"data": "0x0000000000000000000000000000000000000000000000000000000005f5e100",
+			"blockNumber": "0x13f3d1a",
+			"blockTimestamp": "0x6666f1a0",
+			"transactionHash": "0x0000000000000000000000000000000000000000000000000000000000000001",
+			"transactionIndex": "0x0",
+			"logIndex": "0x1",
+			"removed": false
+		}`,
+		Expected: &Transfer{
+			From:  mustParseAddress("0xa9d1e08c7793af67e9d92fe308d5697fb81d3e43"),
+			To:    mustParseAddress("0x77696bb39917c91a0c3908d577d5e322095425ca"),
+			Value: big.NewInt(100_000_000),
+			Token: mustParseAddress("0xdac17f958d2ee523a2206206994597c13d831ec7"),
+
+			BlockTime: time.Unix(0x6666f1a0, 0),
+		},
+	},
+	{
+		Title: "ERC-721 transfer",
+		Log: `{
+			"address": "0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d",
+			"topics": [
+				"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+				"0x000000000000000000000000a9d1e08c7793af67e9d92fe308d5697fb81d3e43",
+				"0x00000000000000000000000077696bb39917c91a0c3908d577d5e322095425ca",
+				"0x0000000000000000000000000000000000000000000000000000000000000001"
+			],
+			"data": "0x",
+			"blockNumber": "0x13f3d1a",
+			"transactionIndex": "0x0",
+			"logIndex": "0x2"
+		}`,
+	},
+	{
+		Title: "Other event",
+		Log: `{
+			"address": "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+			"topics": [
+				"0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925",
+				"0x000000000000000000000000a9d1e08c7793af67e9d92fe308d5697fb81d3e43",
+				"0x00000000000000000000000077696bb39917c91a0c3908d577d5e322095425ca"
+			],
+			"data": "0x0000000000000000000000000000000000000000000000000000000005f5e100",
+			"blockNumber": "0x13f3d1a",
+			"transactionIndex": "0x0",
+			"logIndex": "0x3"
+		}`,
+	},
+}
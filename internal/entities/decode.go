@@ -0,0 +1,168 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	// ErrorMissingField is thrown when a required field of a JSON rpc object is missing
+	ErrorMissingField = errors.New("missing field")
+
+	// ErrorInvalidQuantity is thrown when a JSON rpc quantity is not a 0x prefixed hex number
+	ErrorInvalidQuantity = errors.New("invalid hex quantity")
+
+	// ErrorInvalidTxType is thrown when a transaction type is not a single byte
+	ErrorInvalidTxType = errors.New("invalid transaction type")
+)
+
+// DecodeError is thrown when a JSON rpc object can not be decoded
+type DecodeError struct {
+	// Object kind, e.g. block or transaction
+	Object string
+	Field  string
+	// Number or hash of the block the object belongs to. Empty if unknown
+	Block string
+	// Hash of the transaction the object belongs to. Empty if unknown
+	Tx  string
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	var refs []string
+
+	if e.Block != "" {
+		refs = append(refs, "block "+e.Block)
+	}
+
+	if e.Tx != "" {
+		refs = append(refs, "tx "+e.Tx)
+	}
+
+	if len(refs) == 0 {
+		return fmt.Sprintf("error decode %s field %s. %s", e.Object, e.Field, e.Err)
+	}
+
+	return fmt.Sprintf("error decode %s field %s (%s). %s", e.Object, e.Field, strings.Join(refs, ", "), e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// quantityDecoder decodes hex quantities of a JSON rpc object.
+// Decoding stops at the first error
+type quantityDecoder struct {
+	object string
+	block  string
+	tx     string
+	err    *DecodeError
+}
+
+// required decodes a quantity. Missing quantity is an error
+func (d *quantityDecoder) required(field string, s *string) *big.Int {
+	if s == nil {
+		d.fail(field, ErrorMissingField)
+
+		return nil
+	}
+
+	return d.optional(field, s)
+}
+
+// optional decodes a quantity. Missing quantity is nil
+func (d *quantityDecoder) optional(field string, s *string) *big.Int {
+	if d.err != nil || s == nil {
+		return nil
+	}
+
+	i, err := hexToInt(*s)
+	if err != nil {
+		d.fail(field, err)
+
+		return nil
+	}
+
+	return i
+}
+
+// fail records the first decoding error
+func (d *quantityDecoder) fail(field string, err error) {
+	if d.err != nil {
+		return
+	}
+
+	d.err = &DecodeError{
+		Object: d.object,
+		Field:  field,
+		Block:  d.block,
+		Tx:     d.tx,
+		Err:    err,
+	}
+}
+
+// Err returns the first decoding error
+func (d *quantityDecoder) Err() error {
+	if d.err == nil {
+		return nil
+	}
+
+	return d.err
+}
+
+// quantity converts a big.Int into its JSON rpc quantity representation.
+// nil is converted into nil, so missing quantities stay missing
+func quantity(i *big.Int) *string {
+	if i == nil {
+		return nil
+	}
+
+	s := "0x" + i.Text(16)
+
+	return &s
+}
+
+// ParseQuantity parses a JSON rpc hex encoded quantity
+func ParseQuantity(s string) (*big.Int, error) {
+	return hexToInt(s)
+}
+
+// hexToInt converts a 0x prefixed hex quantity into a non-negative big.Int
+func hexToInt(s string) (*big.Int, error) {
+	if len(s) < 3 || !strings.HasPrefix(s, "0x") {
+		return nil, fmt.Errorf("%w %q", ErrorInvalidQuantity, s)
+	}
+
+	for _, c := range s[2:] {
+		if !isHexDigit(c) {
+			return nil, fmt.Errorf("%w %q", ErrorInvalidQuantity, s)
+		}
+	}
+
+	bi, _ := new(big.Int).SetString(s[2:], 16)
+
+	return bi, nil
+}
+
+// isHexDigit reports whether c is a hex digit
+func isHexDigit(c rune) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+// txType decodes a transaction type. Missing type is TxTypeLegacy
+func (d *quantityDecoder) txType(field string, s *string) TxType {
+	i := d.optional(field, s)
+	if i == nil {
+		return TxTypeLegacy
+	}
+
+	if !i.IsUint64() || i.Uint64() > 0xff {
+		d.fail(field, fmt.Errorf("%w %q", ErrorInvalidTxType, *s))
+
+		return TxTypeLegacy
+	}
+
+	return TxType(i.Uint64())
+}
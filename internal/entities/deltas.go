@@ -0,0 +1,97 @@
+package entities
+
+import "math/big"
+
+// DeltaSet is a set of wallets balance deltas. DeltaSet is not
+// safe for concurrent use
+type DeltaSet struct {
+	// map [Wallet address => Wallet]
+	Wallets map[Address]*Wallet
+	// Total amount of fees burned
+	Burned *big.Int
+	// Transactions totals by type
+	TxTypes TxTypes
+}
+
+// NewDeltaSet returns a new empty DeltaSet
+func NewDeltaSet() *DeltaSet {
+	return &DeltaSet{
+		Wallets: make(map[Address]*Wallet),
+		Burned:  new(big.Int),
+		TxTypes: make(TxTypes),
+	}
+}
+
+// Apply applies the transfer to the set
+func (d *DeltaSet) Apply(t *Transfer) {
+	if t.HasSender() {
+		d.wallet(t.From).ApplySent(t)
+	}
+
+	if t.Kind == TransferKindBurn {
+		d.Burned.Add(d.Burned, t.Value)
+	}
+
+	if t.HasRecipient() {
+		d.wallet(t.To).ApplyReceived(t)
+	}
+
+	d.TxTypes.Apply(t)
+}
+
+// Add adds other set deltas to the set
+func (d *DeltaSet) Add(other *DeltaSet) {
+	for addr, w := range other.Wallets {
+		d.wallet(addr).Add(w)
+	}
+
+	d.Burned.Add(d.Burned, other.Burned)
+	d.TxTypes.Add(other.TxTypes)
+}
+
+// Sub subtracts other set deltas from the set.
+// Wallets left without activity are removed
+func (d *DeltaSet) Sub(other *DeltaSet) {
+	for addr, w := range other.Wallets {
+		wallet := d.wallet(addr)
+		wallet.Sub(w)
+
+		if wallet.IsZero() {
+			delete(d.Wallets, addr)
+		}
+	}
+
+	d.Burned.Sub(d.Burned, other.Burned)
+	d.TxTypes.Sub(other.TxTypes)
+}
+
+// Report returns a report of up to limit wallets ranked the highest
+// by r. Wallets in the report are copies
+func (d *DeltaSet) Report(r Ranking, limit int) *DeltaReport {
+	wallets := make(Wallets, 0, len(d.Wallets))
+	for _, w := range d.Wallets {
+		wallets = append(wallets, w)
+	}
+
+	top := wallets.TopBy(r, limit)
+	for i, w := range top {
+		top[i] = w.Copy()
+	}
+
+	return &DeltaReport{
+		Wallets: top,
+		Burned:  new(big.Int).Set(d.Burned),
+		TxTypes: d.TxTypes.Copy(),
+	}
+}
+
+// wallet returns a wallet by its address. The wallet is created if missing
+func (d *DeltaSet) wallet(addr Address) *Wallet {
+	w, ok := d.Wallets[addr]
+	if !ok {
+		w = NewWallet(addr)
+		d.Wallets[addr] = w
+	}
+
+	return w
+}
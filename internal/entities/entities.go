@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"math/big"
@@ -11,27 +12,275 @@ import (
 // Wallet represents an on-chain wallet with address in hex format and
 // delta of its balance
 type Wallet struct {
-	Address string
-	Delta   *big.Int
+	Address Address
+	// Token contract address. Zero for native ETH
+	Token Address
+	// Signed balance delta. Negative if the wallet lost funds
+	Delta *big.Int
+	// Total amount received by the wallet
+	Inflow *big.Int
+	// Total amount sent by the wallet
+	Outflow *big.Int
+	// Number of transactions the wallet participated in
+	TxCount int
+	// The wallet is a contract created in the window
+	CreatedContract bool
+	// Human readable address label. Nil if not labeled
+	Label *Label
+	// Comparison with the on-chain balance change. Nil if not verified
+	Verification *Verification
+	// Balance deltas by contributing block number
+	BlockDeltas map[uint64]*big.Int
+	// Timestamps of the contributing blocks, if known
+	BlockTimes map[uint64]time.Time
+	// USD value of the delta. Nil if not valued
+	Valuation *Valuation
+}
+
+// NewWallet returns a new Wallet with zero delta
+func NewWallet(address Address) *Wallet {
+	return &Wallet{
+		Address:     address,
+		Delta:       new(big.Int),
+		Inflow:      new(big.Int),
+		Outflow:     new(big.Int),
+		BlockDeltas: make(map[uint64]*big.Int),
+		BlockTimes:  make(map[uint64]time.Time),
+	}
+}
+
+// Credit adds value to the wallet balance
+func (w *Wallet) Credit(value *big.Int) {
+	w.Delta.Add(w.Delta, value)
+	w.Inflow.Add(w.Inflow, value)
+}
+
+// Debit subtracts value from the wallet balance
+func (w *Wallet) Debit(value *big.Int) {
+	w.Delta.Sub(w.Delta, value)
+	w.Outflow.Add(w.Outflow, value)
+}
+
+// ApplySent applies a transfer sent by the wallet
+func (w *Wallet) ApplySent(t *Transfer) {
+	w.Debit(t.Value)
+	w.applyBlockDelta(t.BlockNumber, new(big.Int).Neg(t.Value))
+	w.setBlockTime(t.BlockNumber, t.BlockTime)
+
+	if t.Kind == TransferKindValue {
+		w.TxCount++
+	}
+}
+
+// ApplyReceived applies a transfer received by the wallet
+func (w *Wallet) ApplyReceived(t *Transfer) {
+	w.Credit(t.Value)
+	w.applyBlockDelta(t.BlockNumber, t.Value)
+	w.setBlockTime(t.BlockNumber, t.BlockTime)
+
+	if t.Creation {
+		w.CreatedContract = true
+	}
+
+	// Self transfers are counted once
+	if t.Kind == TransferKindValue && t.From != t.To {
+		w.TxCount++
+	}
+}
+
+// Add adds other wallet stats to the wallet
+func (w *Wallet) Add(other *Wallet) {
+	w.Delta.Add(w.Delta, other.Delta)
+	w.Inflow.Add(w.Inflow, other.Inflow)
+	w.Outflow.Add(w.Outflow, other.Outflow)
+	w.TxCount += other.TxCount
+	w.CreatedContract = w.CreatedContract || other.CreatedContract
+
+	for num, delta := range other.BlockDeltas {
+		w.applyBlockDelta(num, delta)
+	}
+
+	for num, at := range other.BlockTimes {
+		w.setBlockTime(num, at)
+	}
+}
+
+// Sub subtracts other wallet stats from the wallet
+func (w *Wallet) Sub(other *Wallet) {
+	w.Delta.Sub(w.Delta, other.Delta)
+	w.Inflow.Sub(w.Inflow, other.Inflow)
+	w.Outflow.Sub(w.Outflow, other.Outflow)
+	w.TxCount -= other.TxCount
+
+	for num, delta := range other.BlockDeltas {
+		w.applyBlockDelta(num, new(big.Int).Neg(delta))
+	}
+
+	// A contract is created once, so the creation left the window
+	if other.CreatedContract {
+		w.CreatedContract = false
+	}
+}
+
+// applyBlockDelta adds delta to the wallet balance delta in the block.
+// Blocks left with a zero delta are removed along with their timestamps
+func (w *Wallet) applyBlockDelta(num uint64, delta *big.Int) {
+	if w.BlockDeltas == nil {
+		w.BlockDeltas = make(map[uint64]*big.Int)
+	}
+
+	d, ok := w.BlockDeltas[num]
+	if !ok {
+		d = new(big.Int)
+		w.BlockDeltas[num] = d
+	}
+
+	if d.Add(d, delta).Sign() == 0 {
+		delete(w.BlockDeltas, num)
+		delete(w.BlockTimes, num)
+	}
+}
+
+// setBlockTime records the timestamp of a contributing block.
+// Zero timestamps and blocks without a delta are skipped
+func (w *Wallet) setBlockTime(num uint64, at time.Time) {
+	if _, ok := w.BlockDeltas[num]; !ok || at.IsZero() {
+		return
+	}
+
+	if w.BlockTimes == nil {
+		w.BlockTimes = make(map[uint64]time.Time)
+	}
+
+	w.BlockTimes[num] = at
+}
+
+// IsZero reports whether the wallet has no activity
+func (w *Wallet) IsZero() bool {
+	return w.TxCount == 0 && w.Inflow.Sign() == 0 && w.Outflow.Sign() == 0
+}
+
+// Copy returns a deep copy of the wallet
+func (w *Wallet) Copy() *Wallet {
+	c := NewWallet(w.Address)
+	c.Token = w.Token
+	c.Add(w)
+
+	return c
+}
+
+// AbsDelta returns an absolute value of the wallet balance delta
+func (w *Wallet) AbsDelta() *big.Int {
+	return new(big.Int).Abs(w.Delta)
+}
+
+// Volume returns a gross volume of the wallet, inflow + outflow
+func (w *Wallet) Volume() *big.Int {
+	return new(big.Int).Add(w.Inflow, w.Outflow)
 }
 
 // []*Wallet type alias
 type Wallets []*Wallet
 
-// Sort sorts wallets by delta
+// Sort sorts wallets by absolute delta
 func (w Wallets) Sort() {
-	sort.Slice(w, func(r, l int) bool {
-		if r := w[r].Delta.Cmp(w[l].Delta); r < 0 {
-			return true
+	w.SortBy(RankingAbs)
+}
+
+// SortBy sorts wallets from the lowest ranked to the highest. Wallets are
+// ranked by the ranking key, then by tx count, then by the lower address
+// and token, so the order is total and does not depend on the input order
+func (w Wallets) SortBy(r Ranking) {
+	keys := make(map[*Wallet]*big.Int, len(w))
+	for _, wallet := range w {
+		keys[wallet] = r.key(wallet)
+	}
+
+	sort.Slice(w, func(i, j int) bool {
+		if c := keys[w[i]].Cmp(keys[w[j]]); c != 0 {
+			return c < 0
 		}
 
-		return false
+		if w[i].TxCount != w[j].TxCount {
+			return w[i].TxCount < w[j].TxCount
+		}
+
+		if c := bytes.Compare(w[i].Address[:], w[j].Address[:]); c != 0 {
+			return c > 0
+		}
+
+		return bytes.Compare(w[i].Token[:], w[j].Token[:]) > 0
 	})
 }
 
-// BlockNumber is an alias for hex block number
+// Top returns up to limit wallets with the highest absolute delta,
+// ordered from the highest to the lowest
+func (w Wallets) Top(limit int) Wallets {
+	return w.TopBy(RankingAbs, limit)
+}
+
+// TopBy returns up to limit wallets ranked the highest by r,
+// ordered from the highest to the lowest
+func (w Wallets) TopBy(r Ranking, limit int) Wallets {
+	w.SortBy(r)
+
+	top := make(Wallets, 0, len(w))
+
+	for i := len(w) - 1; i >= 0 && (limit <= 0 || len(top) < limit); i-- {
+		if r.ranks(w[i]) {
+			top = append(top, w[i])
+		}
+	}
+
+	return top
+}
+
+// DeltaReport is a result of balance deltas computation over a window of blocks
+type DeltaReport struct {
+	// Wallets ordered from the highest ranked to the lowest
+	Wallets Wallets
+	// Total amount of fees burned in the window
+	Burned *big.Int
+	// Transactions totals of the window by type
+	TxTypes TxTypes
+	// Blocks the report is built from
+	Coverage *Coverage
+}
+
+// Coverage describes which blocks of the requested window were processed
+type Coverage struct {
+	// Window bounds, inclusive
+	FromBlock uint64
+	ToBlock   uint64
+	// Amount of blocks in the window
+	Requested int
+	// Processed block numbers in ascending order
+	Processed []uint64
+	// Block numbers that could not be processed in ascending order
+	Missing []uint64
+}
+
+// Complete reports whether all the blocks of the window were processed
+func (c *Coverage) Complete() bool {
+	return len(c.Missing) == 0
+}
+
+// BlockNumber is an alias for hex block number or block tag
 type BlockNumber string
 
+// Block tags
+const (
+	BlockTagLatest    BlockNumber = "latest"
+	BlockTagSafe      BlockNumber = "safe"
+	BlockTagFinalized BlockNumber = "finalized"
+	BlockTagEarliest  BlockNumber = "earliest"
+)
+
+// NewBlockNumber returns a hex block number of n
+func NewBlockNumber(n *big.Int) BlockNumber {
+	return BlockNumber("0x" + n.Text(16))
+}
+
 // ToInt converts string hex block number into its big.Int representation
 func (n BlockNumber) ToInt() (*big.Int, error) {
 	return hexToInt((string)(n))
@@ -44,13 +293,13 @@ type Block struct {
 	ExtraData        string         `json:"extraData"`
 	GasLimit         *big.Int       `json:"gasLimit"`
 	GasUsed          *big.Int       `json:"gasUsed"`
-	Hash             string         `json:"hash"`
+	Hash             Hash           `json:"hash"`
 	LogsBloom        string         `json:"logsBloom"`
-	Miner            string         `json:"miner"`
+	Miner            Address        `json:"miner"`
 	MixHash          string         `json:"mixHash"`
 	Nonce            string         `json:"nonce"`
 	Number           *big.Int       `json:"number"`
-	ParentHash       string         `json:"parentHash"`
+	ParentHash       Hash           `json:"parentHash"`
 	ReceiptsRoot     string         `json:"receiptsRoot"`
 	Sha3Uncles       string         `json:"sha3Uncles"`
 	Size             *big.Int       `json:"size"`
@@ -59,24 +308,36 @@ type Block struct {
 	TotalDifficulty  *big.Int       `json:"totalDifficulty"`
 	Transactions     []*Transaction `json:"transactions"`
 	TransactionsRoot string         `json:"transactionsRoot"`
+	Withdrawals      []*Withdrawal  `json:"withdrawals"`
+	// EIP-4844 blob gas totals. Nil before Cancun
+	BlobGasUsed   *big.Int `json:"blobGasUsed"`
+	ExcessBlobGas *big.Int `json:"excessBlobGas"`
+	// EIP-4788 beacon block root. Empty before Cancun
+	ParentBeaconBlockRoot string `json:"parentBeaconBlockRoot"`
 }
 
-// helper alias for proper unmarshal of a block object
+// helper alias for proper (un)marshal of a block object
 type blockAlias Block
 
-// blockRaw is an intermediate object needed for unmarshalling
+// blockRaw is an intermediate object needed for (un)marshalling.
+// Missing quantities are nil
 type blockRaw struct {
 	*blockAlias
-	BaseFeePerGas   string `json:"baseFeePerGas"`
-	Difficulty      string `json:"difficulty"`
-	GasLimit        string `json:"gasLimit"`
-	GasUsed         string `json:"gasUsed"`
-	Number          string `json:"number"`
-	Size            string `json:"size"`
-	Timestamp       string `json:"timestamp"`
-	TotalDifficulty string `json:"totalDifficulty"`
+	BaseFeePerGas   *string `json:"baseFeePerGas"`
+	BlobGasUsed     *string `json:"blobGasUsed"`
+	ExcessBlobGas   *string `json:"excessBlobGas"`
+	Difficulty      *string `json:"difficulty"`
+	GasLimit        *string `json:"gasLimit"`
+	GasUsed         *string `json:"gasUsed"`
+	Number          *string `json:"number"`
+	Size            *string `json:"size"`
+	Timestamp       *string `json:"timestamp"`
+	TotalDifficulty *string `json:"totalDifficulty"`
 }
 
+// UnmarshalJSON decodes a JSON rpc block. Malformed quantities and missing
+// required quantities are reported with DecodeError. Missing optional
+// quantities, e.g. baseFeePerGas of pre London blocks, are nil
 func (b *Block) UnmarshalJSON(data []byte) error {
 	raw := &blockRaw{
 		blockAlias: (*blockAlias)(b),
@@ -86,61 +347,142 @@ func (b *Block) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("error unmarshal base block data. %w", err)
 	}
 
-	b.Difficulty = hexToIntMust(raw.Difficulty)
-	b.TotalDifficulty = hexToIntMust(raw.TotalDifficulty)
-	b.BaseFeePerGas = hexToIntMust(raw.BaseFeePerGas)
-	b.GasLimit = hexToIntMust(raw.GasLimit)
-	b.GasUsed = hexToIntMust(raw.GasUsed)
-	b.Number = hexToIntMust(raw.Number)
-	b.Size = hexToIntMust(raw.Size)
-	b.Timestamp = time.Unix(hexToIntMust(raw.Timestamp).Int64(), 0)
+	d := &quantityDecoder{object: "block", block: blockRef(raw.Number, b.Hash)}
 
-	return nil
+	b.Number = d.required("number", raw.Number)
+	b.GasLimit = d.required("gasLimit", raw.GasLimit)
+	b.GasUsed = d.required("gasUsed", raw.GasUsed)
+	b.Difficulty = d.optional("difficulty", raw.Difficulty)
+	b.TotalDifficulty = d.optional("totalDifficulty", raw.TotalDifficulty)
+	b.BaseFeePerGas = d.optional("baseFeePerGas", raw.BaseFeePerGas)
+	b.Size = d.optional("size", raw.Size)
+	b.BlobGasUsed = d.optional("blobGasUsed", raw.BlobGasUsed)
+	b.ExcessBlobGas = d.optional("excessBlobGas", raw.ExcessBlobGas)
+
+	if ts := d.required("timestamp", raw.Timestamp); ts != nil {
+		b.Timestamp = time.Unix(ts.Int64(), 0)
+	}
+
+	return d.Err()
 }
 
-// Transaction object
+// MarshalJSON encodes the block into its JSON rpc representation
+func (b *Block) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&blockRaw{
+		blockAlias:      (*blockAlias)(b),
+		BaseFeePerGas:   quantity(b.BaseFeePerGas),
+		BlobGasUsed:     quantity(b.BlobGasUsed),
+		ExcessBlobGas:   quantity(b.ExcessBlobGas),
+		Difficulty:      quantity(b.Difficulty),
+		GasLimit:        quantity(b.GasLimit),
+		GasUsed:         quantity(b.GasUsed),
+		Number:          quantity(b.Number),
+		Size:            quantity(b.Size),
+		Timestamp:       quantity(big.NewInt(b.Timestamp.Unix())),
+		TotalDifficulty: quantity(b.TotalDifficulty),
+	})
+}
+
+// blockRef returns a block reference for errors: the raw block number if
+// present, the block hash otherwise
+func blockRef(number *string, hash Hash) string {
+	if number != nil {
+		return *number
+	}
+
+	return hashRef(hash)
+}
+
+// hashRef returns a hash reference for errors. Empty if the hash is unknown
+func hashRef(h Hash) string {
+	if h.IsZero() {
+		return ""
+	}
+
+	return h.Hex()
+}
+
+// Transaction object of any envelope type. Fields not used
+// by the transaction type are nil or empty
 type Transaction struct {
-	BlockHash            string        `json:"blockHash"`
-	BlockNumber          *big.Int      `json:"blockNumber"`
-	From                 string        `json:"from"`
-	Gas                  *big.Int      `json:"gas"`
-	GasPrice             *big.Int      `json:"gasPrice"`
-	Hash                 string        `json:"hash"`
-	Input                string        `json:"input"`
-	Nonce                *big.Int      `json:"nonce"`
-	To                   string        `json:"to"`
-	TransactionIndex     *big.Int      `json:"transactionIndex"`
-	Value                *big.Int      `json:"value"`
-	Type                 *big.Int      `json:"type"`
-	V                    *big.Int      `json:"v"`
-	R                    string        `json:"r"`
-	S                    string        `json:"s"`
-	MaxFeePerGas         *big.Int      `json:"maxFeePerGas"`
-	MaxPriorityFeePerGas *big.Int      `json:"maxPriorityFeePerGas"`
-	AccessList           []interface{} `json:"accessList"`
-	ChainID              *big.Int      `json:"chainId"`
-}
-
-// helper alias for proper unmarshal of a transaction object
+	BlockHash        Hash     `json:"blockHash"`
+	BlockNumber      *big.Int `json:"blockNumber"`
+	From             Address  `json:"from"`
+	Gas              *big.Int `json:"gas"`
+	GasPrice         *big.Int `json:"gasPrice"`
+	Hash             Hash     `json:"hash"`
+	Input            string   `json:"input"`
+	Nonce            *big.Int `json:"nonce"`
+	To               *Address `json:"to"`
+	TransactionIndex *big.Int `json:"transactionIndex"`
+	Value            *big.Int `json:"value"`
+	Type             TxType   `json:"type"`
+	V                *big.Int `json:"v"`
+	R                string   `json:"r"`
+	S                string   `json:"s"`
+	// Signature parity of typed transactions
+	YParity *big.Int `json:"yParity"`
+	ChainID *big.Int `json:"chainId"`
+	// EIP-2930 and later
+	AccessList []*AccessTuple `json:"accessList"`
+	// EIP-1559 and later
+	MaxFeePerGas         *big.Int `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *big.Int `json:"maxPriorityFeePerGas"`
+	// EIP-4844
+	MaxFeePerBlobGas    *big.Int `json:"maxFeePerBlobGas"`
+	BlobVersionedHashes []Hash   `json:"blobVersionedHashes"`
+	// EIP-7702
+	AuthorizationList []*Authorization `json:"authorizationList"`
+	// Receipt is fetched separately and may be nil
+	Receipt *Receipt `json:"-"`
+	// Call trace is fetched separately and may be nil
+	Trace *CallFrame `json:"-"`
+}
+
+// IsCreation reports whether the transaction deploys a contract.
+// Address of the created contract is in the transaction receipt
+func (t *Transaction) IsCreation() bool {
+	return t.To == nil
+}
+
+// Failed reports whether the transaction execution reverted according
+// to its attached receipt or call trace
+func (t *Transaction) Failed() bool {
+	return (t.Receipt != nil && t.Receipt.Failed()) || (t.Trace != nil && t.Trace.Error != "")
+}
+
+// helper alias for proper (un)marshal of a transaction object
 type transactionAlias Transaction
 
-// transactionRaw is an intermediate object needed for unmarshalling
+// transactionRaw is an intermediate object needed for (un)marshalling.
+// Missing quantities are nil
 type transactionRaw struct {
 	*transactionAlias
-	BlockNumber          string `json:"blockNumber"`
-	Gas                  string `json:"gas"`
-	GasPrice             string `json:"gasPrice"`
-	Nonce                string `json:"nonce"`
-	TransactionIndex     string `json:"transactionIndex"`
-	Value                string `json:"value"`
-	Type                 string `json:"type"`
-	V                    string `json:"v"`
-	MaxFeePerGas         string `json:"maxFeePerGas"`
-	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas"`
-	ChainID              string `json:"chainId"`
+	BlockNumber          *string `json:"blockNumber"`
+	Gas                  *string `json:"gas"`
+	GasPrice             *string `json:"gasPrice"`
+	Nonce                *string `json:"nonce"`
+	TransactionIndex     *string `json:"transactionIndex"`
+	Value                *string `json:"value"`
+	Type                 *string `json:"type"`
+	V                    *string `json:"v"`
+	YParity              *string `json:"yParity"`
+	MaxFeePerGas         *string `json:"maxFeePerGas"`
+	MaxFeePerBlobGas     *string `json:"maxFeePerBlobGas"`
+	MaxPriorityFeePerGas *string `json:"maxPriorityFeePerGas"`
+	ChainID              *string `json:"chainId"`
 }
 
+// UnmarshalJSON decodes a JSON rpc transaction. Malformed quantities and missing
+// required quantities are reported with DecodeError. Missing optional
+// quantities, e.g. maxFeePerGas of legacy transactions, are nil. Missing
+// type is TxTypeLegacy
 func (t *Transaction) UnmarshalJSON(data []byte) error {
+	// Blocks fetched without transactions details contain transactions hashes only
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &t.Hash)
+	}
+
 	txRaw := &transactionRaw{
 		transactionAlias: (*transactionAlias)(t),
 	}
@@ -149,47 +491,46 @@ func (t *Transaction) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("error unmarshal base tx data. %w", err)
 	}
 
-	t.BlockNumber = hexToIntMust(txRaw.BlockNumber)
-	t.Gas = hexToIntMust(txRaw.Gas)
-	t.GasPrice = hexToIntMust(txRaw.GasPrice)
-	t.Nonce = hexToIntMust(txRaw.Nonce)
-	t.TransactionIndex = hexToIntMust(txRaw.TransactionIndex)
-	t.Value = hexToIntMust(txRaw.Value)
-	t.Type = hexToIntMust(txRaw.Type)
-	t.V = hexToIntMust(txRaw.V)
-	t.MaxFeePerGas = hexToIntMust(txRaw.MaxFeePerGas)
-	t.MaxPriorityFeePerGas = hexToIntMust(txRaw.MaxPriorityFeePerGas)
-	t.ChainID = hexToIntMust(txRaw.ChainID)
-
-	return nil
-}
-
-// hexToIntMust is a shortcur for
-//
-//	i, err := hexToInt(s)
-//	if err != nil {
-//		panic(err)
-//	}
-func hexToIntMust(s string) *big.Int {
-	i, err := hexToInt(s)
-	if err != nil {
-		panic(err)
+	d := &quantityDecoder{
+		object: "transaction",
+		block:  blockRef(txRaw.BlockNumber, t.BlockHash),
+		tx:     hashRef(t.Hash),
 	}
 
-	return i
-}
+	t.Gas = d.required("gas", txRaw.Gas)
+	t.Nonce = d.required("nonce", txRaw.Nonce)
+	t.Value = d.required("value", txRaw.Value)
+	// Pending transactions have no block
+	t.BlockNumber = d.optional("blockNumber", txRaw.BlockNumber)
+	t.TransactionIndex = d.optional("transactionIndex", txRaw.TransactionIndex)
+	t.GasPrice = d.optional("gasPrice", txRaw.GasPrice)
+	t.Type = d.txType("type", txRaw.Type)
+	t.V = d.optional("v", txRaw.V)
+	t.YParity = d.optional("yParity", txRaw.YParity)
+	t.MaxFeePerGas = d.optional("maxFeePerGas", txRaw.MaxFeePerGas)
+	t.MaxPriorityFeePerGas = d.optional("maxPriorityFeePerGas", txRaw.MaxPriorityFeePerGas)
+	t.MaxFeePerBlobGas = d.optional("maxFeePerBlobGas", txRaw.MaxFeePerBlobGas)
+	t.ChainID = d.optional("chainId", txRaw.ChainID)
 
-// hexToInt converts string hex value into a big.Int
-func hexToInt(s string) (*big.Int, error) {
-	bi := new(big.Int)
-
-	if len(s) < 2 {
-		return bi, nil
-	}
-
-	if _, ok := bi.SetString(s[2:], 16); !ok {
-		return nil, fmt.Errorf("error invalid hex number")
-	}
+	return d.Err()
+}
 
-	return bi, nil
+// MarshalJSON encodes the transaction into its JSON rpc representation
+func (t *Transaction) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&transactionRaw{
+		transactionAlias:     (*transactionAlias)(t),
+		BlockNumber:          quantity(t.BlockNumber),
+		Gas:                  quantity(t.Gas),
+		GasPrice:             quantity(t.GasPrice),
+		Nonce:                quantity(t.Nonce),
+		TransactionIndex:     quantity(t.TransactionIndex),
+		Value:                quantity(t.Value),
+		Type:                 quantity(t.Type.quantity()),
+		V:                    quantity(t.V),
+		YParity:              quantity(t.YParity),
+		MaxFeePerGas:         quantity(t.MaxFeePerGas),
+		MaxFeePerBlobGas:     quantity(t.MaxFeePerBlobGas),
+		MaxPriorityFeePerGas: quantity(t.MaxPriorityFeePerGas),
+		ChainID:              quantity(t.ChainID),
+	})
 }
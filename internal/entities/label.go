@@ -0,0 +1,18 @@
+package entities
+
+// Common label categories
+const (
+	LabelCategoryExchange = "exchange"
+	LabelCategoryBridge   = "bridge"
+	LabelCategoryMEVBot   = "mev_bot"
+	LabelCategoryToken    = "token"
+)
+
+// Label is a human readable name of an address
+type Label struct {
+	Name string `json:"name"`
+	// Category, e.g. exchange, bridge, mev_bot, token. Lower case
+	Category string `json:"category"`
+	// Ticker symbol of a token, e.g. USDT. Empty if unknown
+	Symbol string `json:"symbol,omitempty"`
+}
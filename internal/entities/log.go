@@ -0,0 +1,143 @@
+package entities
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// ERC20TransferTopic is the keccak256 hash of the Transfer(address,address,uint256) event signature
+var ERC20TransferTopic = mustParseHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+// Log is an event log emitted by a contract
+type Log struct {
+	Address          Address  `json:"address"`
+	Topics           []Hash   `json:"topics"`
+	Data             string   `json:"data"`
+	BlockNumber      *big.Int `json:"blockNumber"`
+	BlockHash        Hash     `json:"blockHash"`
+	TransactionHash  Hash     `json:"transactionHash"`
+	TransactionIndex *big.Int `json:"transactionIndex"`
+	LogIndex         *big.Int `json:"logIndex"`
+	Removed          bool     `json:"removed"`
+	// Unix time of the block. Nil if the node does not return it
+	BlockTimestamp *big.Int `json:"blockTimestamp"`
+}
+
+// ERC20Transfer decodes an ERC-20 Transfer event. ok is false if the log is not
+// an ERC-20 Transfer event, e.g. an ERC-721 Transfer with an indexed token id
+func (l *Log) ERC20Transfer() (*Transfer, bool) {
+	if len(l.Topics) != 3 || l.Topics[0] != ERC20TransferTopic {
+		return nil, false
+	}
+
+	from, ok := topicAddress(l.Topics[1])
+	if !ok {
+		return nil, false
+	}
+
+	to, ok := topicAddress(l.Topics[2])
+	if !ok {
+		return nil, false
+	}
+
+	// Value is a single 32 bytes word
+	if len(l.Data) != 66 {
+		return nil, false
+	}
+
+	value, err := hexToInt(l.Data)
+	if err != nil {
+		return nil, false
+	}
+
+	t := &Transfer{
+		From:   from,
+		To:     to,
+		Value:  value,
+		Kind:   TransferKindValue,
+		TxHash: l.TransactionHash,
+		Token:  l.Address,
+	}
+
+	if l.BlockNumber != nil {
+		t.BlockNumber = l.BlockNumber.Uint64()
+	}
+
+	if l.BlockTimestamp != nil {
+		t.BlockTime = time.Unix(l.BlockTimestamp.Int64(), 0)
+	}
+
+	return t, true
+}
+
+// topicAddress decodes an address from an indexed topic
+func topicAddress(topic Hash) (Address, bool) {
+	// 12 zero bytes + 20 address bytes
+	var padding [HashLength - AddressLength]byte
+	if !bytes.Equal(topic[:len(padding)], padding[:]) {
+		return Address{}, false
+	}
+
+	return Address(topic[HashLength-AddressLength:]), true
+}
+
+// LogFilter is an eth_getLogs filter of logs emitted in the [FromBlock, ToBlock] range
+type LogFilter struct {
+	FromBlock BlockNumber `json:"fromBlock"`
+	ToBlock   BlockNumber `json:"toBlock"`
+	// Emitting contracts. Empty means any
+	Addresses []Address `json:"address,omitempty"`
+	// Topics by position. Every position matches any of its topics
+	Topics [][]Hash `json:"topics,omitempty"`
+}
+
+// helper alias for proper (un)marshal of a log object
+type logAlias Log
+
+// logRaw is an intermediate object needed for (un)marshalling.
+// Missing quantities are nil
+type logRaw struct {
+	*logAlias
+	BlockNumber      *string `json:"blockNumber"`
+	BlockTimestamp   *string `json:"blockTimestamp"`
+	TransactionIndex *string `json:"transactionIndex"`
+	LogIndex         *string `json:"logIndex"`
+}
+
+// UnmarshalJSON decodes a JSON rpc log. Quantities of pending logs are nil
+func (l *Log) UnmarshalJSON(data []byte) error {
+	raw := &logRaw{
+		logAlias: (*logAlias)(l),
+	}
+
+	if err := json.Unmarshal(data, raw); err != nil {
+		return fmt.Errorf("error unmarshal base log data. %w", err)
+	}
+
+	d := &quantityDecoder{
+		object: "log",
+		block:  blockRef(raw.BlockNumber, l.BlockHash),
+		tx:     hashRef(l.TransactionHash),
+	}
+
+	l.BlockNumber = d.optional("blockNumber", raw.BlockNumber)
+	l.BlockTimestamp = d.optional("blockTimestamp", raw.BlockTimestamp)
+	l.TransactionIndex = d.optional("transactionIndex", raw.TransactionIndex)
+	l.LogIndex = d.optional("logIndex", raw.LogIndex)
+
+	return d.Err()
+}
+
+// MarshalJSON encodes the log into its JSON rpc representation
+func (l *Log) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&logRaw{
+		logAlias:         (*logAlias)(l),
+		BlockNumber:      quantity(l.BlockNumber),
+		BlockTimestamp:   quantity(l.BlockTimestamp),
+		TransactionIndex: quantity(l.TransactionIndex),
+		LogIndex:         quantity(l.LogIndex),
+	})
+}
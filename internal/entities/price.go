@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"math/big"
+	"time"
+)
+
+// SymbolETH is the symbol of native ETH
+const SymbolETH = "ETH"
+
+// Asset is a priced asset, native ETH or a token
+type Asset struct {
+	// Token contract address. Zero for native ETH
+	Token Address
+	// Ticker symbol, e.g. ETH or USDC. Empty if unknown
+	Symbol string
+}
+
+// Price is an asset USD price at some moment
+type Price struct {
+	// USD price of a single whole unit of the asset
+	USD *big.Rat
+	// Amount of decimal digits of the asset base units, e.g. 18 for ETH
+	Decimals int
+	// Moment the price was observed at
+	Time time.Time
+}
+
+// Value returns the USD value of the amount of the asset base units
+func (p *Price) Value(amount *big.Int) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(p.Decimals)), nil)
+
+	v := new(big.Rat).SetFrac(amount, scale)
+
+	return v.Mul(v, p.USD)
+}
+
+// Valuation is a USD value of a wallet balance delta. Every contributing
+// block delta is priced at the block timestamp
+type Valuation struct {
+	// USD value of the delta. Nil if some block could not be priced
+	DeltaUSD *big.Rat
+	// Not empty if the delta could not be priced
+	Error string
+}
@@ -0,0 +1,63 @@
+package entities
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Ranking is a wallets ranking mode
+type Ranking string
+
+const (
+	// Largest absolute balance change
+	RankingAbs Ranking = "abs"
+	// Largest net gain. Wallets without a gain are not ranked
+	RankingGain Ranking = "gain"
+	// Largest net loss. Wallets without a loss are not ranked
+	RankingLoss Ranking = "loss"
+	// Largest gross volume, inflow + outflow
+	RankingVolume Ranking = "volume"
+)
+
+// ParseRanking parses a ranking mode. An empty string means RankingAbs
+func ParseRanking(s string) (Ranking, error) {
+	switch r := Ranking(strings.ToLower(s)); r {
+	case "":
+		return RankingAbs, nil
+	case RankingAbs, RankingGain, RankingLoss, RankingVolume:
+		return r, nil
+	default:
+		return "", fmt.Errorf("error unknown ranking %q", s)
+	}
+}
+
+// key returns a wallet ranking key. Wallets with higher keys rank higher
+func (r Ranking) key(w *Wallet) *big.Int {
+	switch r {
+	case RankingGain:
+		return w.Delta
+	case RankingLoss:
+		return new(big.Int).Neg(w.Delta)
+	case RankingVolume:
+		return w.Volume()
+	case RankingAbs:
+		return w.AbsDelta()
+	}
+
+	return w.AbsDelta()
+}
+
+// ranks reports whether the wallet takes part in the ranking
+func (r Ranking) ranks(w *Wallet) bool {
+	switch r {
+	case RankingGain:
+		return w.Delta.Sign() > 0
+	case RankingLoss:
+		return w.Delta.Sign() < 0
+	case RankingAbs, RankingVolume:
+		return true
+	}
+
+	return true
+}
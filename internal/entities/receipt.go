@@ -0,0 +1,116 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// Receipt is a transaction receipt object
+type Receipt struct {
+	BlockHash         Hash     `json:"blockHash"`
+	BlockNumber       *big.Int `json:"blockNumber"`
+	ContractAddress   *Address `json:"contractAddress"`
+	CumulativeGasUsed *big.Int `json:"cumulativeGasUsed"`
+	EffectiveGasPrice *big.Int `json:"effectiveGasPrice"`
+	From              Address  `json:"from"`
+	GasUsed           *big.Int `json:"gasUsed"`
+	BlobGasUsed       *big.Int `json:"blobGasUsed"`
+	BlobGasPrice      *big.Int `json:"blobGasPrice"`
+	Status            *big.Int `json:"status"`
+	To                *Address `json:"to"`
+	TransactionHash   Hash     `json:"transactionHash"`
+	TransactionIndex  *big.Int `json:"transactionIndex"`
+	Type              TxType   `json:"type"`
+	Logs              []*Log   `json:"logs"`
+}
+
+// Fee returns the total amount paid by the sender for the transaction execution.
+// It includes blob gas fee for blob transactions.
+func (r *Receipt) Fee() *big.Int {
+	fee := new(big.Int).Mul(r.GasUsed, r.EffectiveGasPrice)
+
+	return fee.Add(fee, r.BlobFee())
+}
+
+// Failed reports whether the transaction execution reverted. Receipts
+// without status, i.e. pre-Byzantium ones, are not failed
+func (r *Receipt) Failed() bool {
+	return r.Status != nil && r.Status.Sign() == 0
+}
+
+// BlobFee returns the amount paid for blob gas. Blob fee is always burned.
+// Non blob transactions have zero blob fee
+func (r *Receipt) BlobFee() *big.Int {
+	if r.BlobGasUsed == nil || r.BlobGasPrice == nil {
+		return new(big.Int)
+	}
+
+	return new(big.Int).Mul(r.BlobGasUsed, r.BlobGasPrice)
+}
+
+// helper alias for proper (un)marshal of a receipt object
+type receiptAlias Receipt
+
+// receiptRaw is an intermediate object needed for (un)marshalling.
+// Missing quantities are nil
+type receiptRaw struct {
+	*receiptAlias
+	BlockNumber       *string `json:"blockNumber"`
+	CumulativeGasUsed *string `json:"cumulativeGasUsed"`
+	EffectiveGasPrice *string `json:"effectiveGasPrice"`
+	GasUsed           *string `json:"gasUsed"`
+	BlobGasUsed       *string `json:"blobGasUsed"`
+	BlobGasPrice      *string `json:"blobGasPrice"`
+	Status            *string `json:"status"`
+	TransactionIndex  *string `json:"transactionIndex"`
+	Type              *string `json:"type"`
+}
+
+// UnmarshalJSON decodes a JSON rpc receipt. Malformed quantities and missing
+// required quantities are reported with DecodeError. Blob gas quantities of
+// non blob transactions are nil
+func (r *Receipt) UnmarshalJSON(data []byte) error {
+	raw := &receiptRaw{
+		receiptAlias: (*receiptAlias)(r),
+	}
+
+	if err := json.Unmarshal(data, raw); err != nil {
+		return fmt.Errorf("error unmarshal base receipt data. %w", err)
+	}
+
+	d := &quantityDecoder{
+		object: "receipt",
+		block:  blockRef(raw.BlockNumber, r.BlockHash),
+		tx:     hashRef(r.TransactionHash),
+	}
+
+	r.BlockNumber = d.required("blockNumber", raw.BlockNumber)
+	r.CumulativeGasUsed = d.required("cumulativeGasUsed", raw.CumulativeGasUsed)
+	r.EffectiveGasPrice = d.required("effectiveGasPrice", raw.EffectiveGasPrice)
+	r.GasUsed = d.required("gasUsed", raw.GasUsed)
+	r.TransactionIndex = d.required("transactionIndex", raw.TransactionIndex)
+	r.BlobGasUsed = d.optional("blobGasUsed", raw.BlobGasUsed)
+	r.BlobGasPrice = d.optional("blobGasPrice", raw.BlobGasPrice)
+	// Pre Byzantium receipts have a state root instead of the status
+	r.Status = d.optional("status", raw.Status)
+	r.Type = d.txType("type", raw.Type)
+
+	return d.Err()
+}
+
+// MarshalJSON encodes the receipt into its JSON rpc representation
+func (r *Receipt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&receiptRaw{
+		receiptAlias:      (*receiptAlias)(r),
+		BlockNumber:       quantity(r.BlockNumber),
+		CumulativeGasUsed: quantity(r.CumulativeGasUsed),
+		EffectiveGasPrice: quantity(r.EffectiveGasPrice),
+		GasUsed:           quantity(r.GasUsed),
+		BlobGasUsed:       quantity(r.BlobGasUsed),
+		BlobGasPrice:      quantity(r.BlobGasPrice),
+		Status:            quantity(r.Status),
+		TransactionIndex:  quantity(r.TransactionIndex),
+		Type:              quantity(r.Type.quantity()),
+	})
+}
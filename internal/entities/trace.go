@@ -0,0 +1,112 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// Call frame types moving value between addresses
+const (
+	CallTypeCall         = "CALL"
+	CallTypeCreate       = "CREATE"
+	CallTypeCreate2      = "CREATE2"
+	CallTypeSelfDestruct = "SELFDESTRUCT"
+)
+
+// TxTrace is a transaction call trace produced by the callTracer
+type TxTrace struct {
+	TxHash Hash       `json:"txHash"`
+	Result *CallFrame `json:"result"`
+}
+
+// CallFrame is a single call of a transaction call tree
+type CallFrame struct {
+	Type  string   `json:"type"`
+	From  Address  `json:"from"`
+	To    Address  `json:"to"`
+	Value *big.Int `json:"value"`
+	// Not empty if the call was reverted
+	Error string       `json:"error"`
+	Calls []*CallFrame `json:"calls"`
+}
+
+// InternalTransfers returns value transfers made by the nested calls of the
+// frame. The frame itself is a transaction and is not included. Reverted
+// calls and their nested calls are skipped, so a reverted transaction
+// has no internal transfers.
+func (f *CallFrame) InternalTransfers(txHash Hash) []*Transfer {
+	if f.Error != "" {
+		return nil
+	}
+
+	var out []*Transfer
+
+	for _, c := range f.Calls {
+		out = c.appendTransfers(out, txHash)
+	}
+
+	return out
+}
+
+// appendTransfers appends value transfers of the frame and its nested calls to out
+func (f *CallFrame) appendTransfers(out []*Transfer, txHash Hash) []*Transfer {
+	if f.Error != "" {
+		return out
+	}
+
+	switch f.Type {
+	case CallTypeCall, CallTypeCreate, CallTypeCreate2, CallTypeSelfDestruct:
+		if f.Value != nil && f.Value.Sign() > 0 {
+			out = append(out, &Transfer{
+				From:   f.From,
+				To:     f.To,
+				Value:  f.Value,
+				Kind:   TransferKindInternal,
+				TxHash: txHash,
+				// Created contract address is the call recipient
+				Creation: f.Type == CallTypeCreate || f.Type == CallTypeCreate2,
+			})
+		}
+	}
+
+	for _, c := range f.Calls {
+		out = c.appendTransfers(out, txHash)
+	}
+
+	return out
+}
+
+// helper alias for proper (un)marshal of a call frame object
+type callFrameAlias CallFrame
+
+// callFrameRaw is an intermediate object needed for (un)marshalling
+type callFrameRaw struct {
+	*callFrameAlias
+	Value *string `json:"value,omitempty"`
+}
+
+func (f *CallFrame) UnmarshalJSON(data []byte) error {
+	raw := &callFrameRaw{
+		callFrameAlias: (*callFrameAlias)(f),
+	}
+
+	if err := json.Unmarshal(data, raw); err != nil {
+		return fmt.Errorf("error unmarshal base call frame data. %w", err)
+	}
+
+	d := &quantityDecoder{object: "call frame"}
+
+	// Value is omitted for calls that can not carry it, e.g. STATICCALL
+	f.Value = d.optional("value", raw.Value)
+
+	return d.Err()
+}
+
+// MarshalJSON encodes the call frame into its callTracer representation
+func (f *CallFrame) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&callFrameRaw{
+		callFrameAlias: (*callFrameAlias)(f),
+		Value:          quantity(f.Value),
+	})
+}
@@ -0,0 +1,80 @@
+package entities
+
+import (
+	"math/big"
+	"time"
+)
+
+// TransferKind describes the origin of a transfer
+type TransferKind uint8
+
+const (
+	// Value transferred by a transaction
+	TransferKindValue TransferKind = iota
+	// Priority fee paid by a transaction sender to the block fee recipient
+	TransferKindTip
+	// Base fee and blob fee paid by a transaction sender. Burned fee has no recipient
+	TransferKindBurn
+	// Value transferred by a contract call nested into a transaction
+	TransferKindInternal
+	// Priority fee credited to the block fee recipient. Reward has no sender
+	TransferKindReward
+	// Beacon chain withdrawal. Withdrawal has no sender
+	TransferKindWithdrawal
+)
+
+// Transfer is a single movement of funds between two addresses.
+// Every balance change is expressed as a transfer.
+type Transfer struct {
+	From   Address
+	To     Address
+	Value  *big.Int
+	Kind   TransferKind
+	TxHash Hash
+	// Number of the block the transfer is included in
+	BlockNumber uint64
+	// Timestamp of the block the transfer is included in. Zero if unknown
+	BlockTime time.Time
+	// Type of the transaction causing the transfer
+	TxType TxType
+	// Token contract address. Zero for native ETH
+	Token Address
+	// The recipient is a contract created by the transfer
+	Creation bool
+}
+
+// String returns a lower case name of the kind
+func (k TransferKind) String() string {
+	switch k {
+	case TransferKindValue:
+		return "value"
+	case TransferKindTip:
+		return "tip"
+	case TransferKindBurn:
+		return "burn"
+	case TransferKindInternal:
+		return "internal"
+	case TransferKindReward:
+		return "reward"
+	case TransferKindWithdrawal:
+		return "withdrawal"
+	default:
+		return "unknown"
+	}
+}
+
+// Involves reports whether the address is the transfer sender or recipient
+func (t *Transfer) Involves(address Address) bool {
+	return t.From == address || t.To == address
+}
+
+// HasSender reports whether the transfer debits its sender
+func (t *Transfer) HasSender() bool {
+	return t.Kind != TransferKindReward && t.Kind != TransferKindWithdrawal
+}
+
+// HasRecipient reports whether the transfer credits its recipient. Creations
+// with unknown created contract address have no recipient
+func (t *Transfer) HasRecipient() bool {
+	return t.Kind != TransferKindBurn && !(t.Creation && t.To.IsZero())
+}
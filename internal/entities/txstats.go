@@ -0,0 +1,99 @@
+package entities
+
+import "math/big"
+
+// TxTypeStats are totals of the transactions of a single type
+type TxTypeStats struct {
+	Transactions int
+	// Value transferred by the transactions
+	Value *big.Int
+	// Priority fees credited to the block fee recipients
+	Tips *big.Int
+	// Base fees and blob fees burned
+	Burned *big.Int
+}
+
+// newTxTypeStats returns zero stats
+func newTxTypeStats() *TxTypeStats {
+	return &TxTypeStats{
+		Value:  new(big.Int),
+		Tips:   new(big.Int),
+		Burned: new(big.Int),
+	}
+}
+
+// IsZero reports whether the stats have no transactions and fees
+func (s *TxTypeStats) IsZero() bool {
+	return s.Transactions == 0 && s.Value.Sign() == 0 && s.Tips.Sign() == 0 && s.Burned.Sign() == 0
+}
+
+// TxTypes is a breakdown of the transactions totals by type. Token
+// transfers and withdrawals are not included
+type TxTypes map[TxType]*TxTypeStats
+
+// Apply applies the transfer to the stats of its transaction type
+func (b TxTypes) Apply(t *Transfer) {
+	if !t.Token.IsZero() {
+		return
+	}
+
+	switch t.Kind {
+	case TransferKindValue:
+		s := b.stats(t.TxType)
+		s.Transactions++
+		s.Value.Add(s.Value, t.Value)
+	case TransferKindTip, TransferKindReward:
+		s := b.stats(t.TxType)
+		s.Tips.Add(s.Tips, t.Value)
+	case TransferKindBurn:
+		s := b.stats(t.TxType)
+		s.Burned.Add(s.Burned, t.Value)
+	case TransferKindInternal, TransferKindWithdrawal:
+	}
+}
+
+// Add adds other breakdown totals to the breakdown
+func (b TxTypes) Add(other TxTypes) {
+	for tt, o := range other {
+		s := b.stats(tt)
+		s.Transactions += o.Transactions
+		s.Value.Add(s.Value, o.Value)
+		s.Tips.Add(s.Tips, o.Tips)
+		s.Burned.Add(s.Burned, o.Burned)
+	}
+}
+
+// Sub subtracts other breakdown totals from the breakdown.
+// Types left without transactions and fees are removed
+func (b TxTypes) Sub(other TxTypes) {
+	for tt, o := range other {
+		s := b.stats(tt)
+		s.Transactions -= o.Transactions
+		s.Value.Sub(s.Value, o.Value)
+		s.Tips.Sub(s.Tips, o.Tips)
+		s.Burned.Sub(s.Burned, o.Burned)
+
+		if s.IsZero() {
+			delete(b, tt)
+		}
+	}
+}
+
+// Copy returns a deep copy of the breakdown
+func (b TxTypes) Copy() TxTypes {
+	c := make(TxTypes, len(b))
+	c.Add(b)
+
+	return c
+}
+
+// stats returns stats of the type. The stats are created if missing
+func (b TxTypes) stats(tt TxType) *TxTypeStats {
+	s, ok := b[tt]
+	if !ok {
+		s = newTxTypeStats()
+		b[tt] = s
+	}
+
+	return s
+}
@@ -0,0 +1,104 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// TxType is a transaction envelope type
+type TxType uint8
+
+const (
+	// Legacy transaction
+	TxTypeLegacy TxType = iota
+	// EIP-2930 transaction with an access list
+	TxTypeAccessList
+	// EIP-1559 transaction with a dynamic fee
+	TxTypeDynamicFee
+	// EIP-4844 transaction carrying blobs
+	TxTypeBlob
+	// EIP-7702 transaction setting accounts code
+	TxTypeSetCode
+)
+
+// String returns a lower case name of the type. Unknown types are named by their hex number
+func (t TxType) String() string {
+	switch t {
+	case TxTypeLegacy:
+		return "legacy"
+	case TxTypeAccessList:
+		return "access_list"
+	case TxTypeDynamicFee:
+		return "dynamic_fee"
+	case TxTypeBlob:
+		return "blob"
+	case TxTypeSetCode:
+		return "set_code"
+	default:
+		return fmt.Sprintf("0x%x", uint8(t))
+	}
+}
+
+// quantity returns the type as a JSON rpc quantity
+func (t TxType) quantity() *big.Int {
+	return big.NewInt(int64(t))
+}
+
+// AccessTuple is an EIP-2930 access list entry
+type AccessTuple struct {
+	Address     Address `json:"address"`
+	StorageKeys []Hash  `json:"storageKeys"`
+}
+
+// Authorization is an EIP-7702 authorization to set the authority account code
+// to the code of Address
+type Authorization struct {
+	ChainID *big.Int `json:"chainId"`
+	Address Address  `json:"address"`
+	Nonce   *big.Int `json:"nonce"`
+	YParity *big.Int `json:"yParity"`
+	R       string   `json:"r"`
+	S       string   `json:"s"`
+}
+
+// helper alias for proper (un)marshal of an authorization object
+type authorizationAlias Authorization
+
+// authorizationRaw is an intermediate object needed for (un)marshalling.
+// Missing quantities are nil
+type authorizationRaw struct {
+	*authorizationAlias
+	ChainID *string `json:"chainId"`
+	Nonce   *string `json:"nonce"`
+	YParity *string `json:"yParity"`
+}
+
+// UnmarshalJSON decodes a JSON rpc authorization. All the quantities are required
+func (a *Authorization) UnmarshalJSON(data []byte) error {
+	raw := &authorizationRaw{
+		authorizationAlias: (*authorizationAlias)(a),
+	}
+
+	if err := json.Unmarshal(data, raw); err != nil {
+		return fmt.Errorf("error unmarshal base authorization data. %w", err)
+	}
+
+	d := &quantityDecoder{object: "authorization"}
+
+	a.ChainID = d.required("chainId", raw.ChainID)
+	a.Nonce = d.required("nonce", raw.Nonce)
+	a.YParity = d.required("yParity", raw.YParity)
+
+	return d.Err()
+}
+
+// MarshalJSON encodes the authorization into its JSON rpc representation
+func (a *Authorization) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&authorizationRaw{
+		authorizationAlias: (*authorizationAlias)(a),
+		ChainID:            quantity(a.ChainID),
+		Nonce:              quantity(a.Nonce),
+		YParity:            quantity(a.YParity),
+	})
+}
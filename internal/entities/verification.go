@@ -0,0 +1,37 @@
+package entities
+
+import "math/big"
+
+// VerificationHint is a possible cause of a verification discrepancy
+type VerificationHint string
+
+const (
+	// Some blocks of the window were not processed
+	HintMissingBlocks VerificationHint = "missing_blocks"
+	// Gas fees are not accounted
+	HintFees VerificationHint = "fees"
+	// Fee recipient priority fees are not accounted
+	HintRewards VerificationHint = "rewards"
+	// Internal contract transfers are not accounted
+	HintInternalTransfers VerificationHint = "internal_transfers"
+	// Beacon chain withdrawals are not accounted
+	HintWithdrawals VerificationHint = "withdrawals"
+)
+
+// Verification is a comparison of a computed wallet balance delta
+// with the on-chain balance change over the same window
+type Verification struct {
+	// On-chain balance change
+	Actual *big.Int
+	// Actual minus computed delta
+	Discrepancy *big.Int
+	// Possible causes of a non-zero discrepancy
+	Hints []VerificationHint
+	// Not empty if the on-chain balances could not be fetched
+	Error string
+}
+
+// Matches reports whether the computed delta matches the on-chain balance change
+func (v *Verification) Matches() bool {
+	return v.Error == "" && v.Discrepancy.Sign() == 0
+}
@@ -0,0 +1,65 @@
+package entities
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// Wei in a single gwei
+var weiPerGwei = big.NewInt(1_000_000_000)
+
+// Withdrawal is a beacon chain validator withdrawal credited to an address
+type Withdrawal struct {
+	Index          *big.Int `json:"index"`
+	ValidatorIndex *big.Int `json:"validatorIndex"`
+	Address        Address  `json:"address"`
+	// Withdrawn amount in gwei
+	Amount *big.Int `json:"amount"`
+}
+
+// AmountWei returns the withdrawn amount in wei
+func (w *Withdrawal) AmountWei() *big.Int {
+	return new(big.Int).Mul(w.Amount, weiPerGwei)
+}
+
+// helper alias for proper (un)marshal of a withdrawal object
+type withdrawalAlias Withdrawal
+
+// withdrawalRaw is an intermediate object needed for (un)marshalling.
+// Missing quantities are nil
+type withdrawalRaw struct {
+	*withdrawalAlias
+	Index          *string `json:"index"`
+	ValidatorIndex *string `json:"validatorIndex"`
+	Amount         *string `json:"amount"`
+}
+
+// UnmarshalJSON decodes a JSON rpc withdrawal. All the quantities are required
+func (w *Withdrawal) UnmarshalJSON(data []byte) error {
+	raw := &withdrawalRaw{
+		withdrawalAlias: (*withdrawalAlias)(w),
+	}
+
+	if err := json.Unmarshal(data, raw); err != nil {
+		return fmt.Errorf("error unmarshal base withdrawal data. %w", err)
+	}
+
+	d := &quantityDecoder{object: "withdrawal"}
+
+	w.Index = d.required("index", raw.Index)
+	w.ValidatorIndex = d.required("validatorIndex", raw.ValidatorIndex)
+	w.Amount = d.required("amount", raw.Amount)
+
+	return d.Err()
+}
+
+// MarshalJSON encodes the withdrawal into its JSON rpc representation
+func (w *Withdrawal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&withdrawalRaw{
+		withdrawalAlias: (*withdrawalAlias)(w),
+		Index:           quantity(w.Index),
+		ValidatorIndex:  quantity(w.ValidatorIndex),
+		Amount:          quantity(w.Amount),
+	})
+}
@@ -0,0 +1,539 @@
+// blockcache package contains a node client decorator that
+// persists final blocks in an embedded on-disk store
+package blockcache
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+	"github.com/optclblast/blk/internal/logger"
+	"github.com/optclblast/blk/internal/usecase"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	defaultMaxBlocks = 10_000
+	// Fallback finality depth for providers without the finalized tag support
+	defaultFinalityDepth = 64
+	finalizedTTL         = time.Minute
+)
+
+var (
+	// [Block hash => Block JSON]
+	blocksBucket = []byte("blocks")
+	// [Block hash => Receipts JSON]
+	receiptsBucket = []byte("receipts")
+	// [Block number => Block hash]
+	numbersBucket = []byte("numbers")
+	// Store statistics
+	metaBucket = []byte("meta")
+
+	countKey = []byte("count")
+	bytesKey = []byte("bytes")
+)
+
+// Cache is a usecase.NodeClient decorator. Blocks and receipts below the
+// finalized height never change, so they are stored on disk and served
+// without RPC calls. Blocks near the head are always fetched from the node.
+// When the store exceeds its limits, the lowest blocks are evicted first.
+type Cache struct {
+	usecase.NodeClient
+
+	log           *slog.Logger
+	db            *bolt.DB
+	maxBlocks     uint64
+	maxBytes      uint64
+	finalityDepth uint64
+
+	mu              sync.Mutex
+	finalized       uint64
+	finalizedExpiry time.Time
+}
+
+// New opens the store at path and returns a new Cache wrapped around client
+func New(
+	log *slog.Logger,
+	client usecase.NodeClient,
+	path string,
+	opts ...Option,
+) (*Cache, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("error open block cache. %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{blocksBucket, receiptsBucket, numbersBucket, metaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("error init block cache. %w", err), db.Close())
+	}
+
+	c := &Cache{
+		NodeClient:    client,
+		log:           log,
+		db:            db,
+		maxBlocks:     defaultMaxBlocks,
+		finalityDepth: defaultFinalityDepth,
+	}
+
+	// Apply options
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// Option configures Cache
+type Option func(c *Cache)
+
+// MaxBlocks sets a specific maximum amount of stored blocks
+func MaxBlocks(blocks uint64) Option {
+	return func(c *Cache) {
+		if blocks > 0 {
+			c.maxBlocks = blocks
+		}
+	}
+}
+
+// MaxBytes sets a specific maximum size of stored blocks and receipts.
+// Zero means no limit
+func MaxBytes(bytes uint64) Option {
+	return func(c *Cache) {
+		c.maxBytes = bytes
+	}
+}
+
+// FinalityDepth sets a specific amount of blocks below the head that are
+// considered final if the provider does not support the finalized tag
+func FinalityDepth(depth uint64) Option {
+	return func(c *Cache) {
+		c.finalityDepth = depth
+	}
+}
+
+// Close closes the store
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
+
+// BlockInfoByNumber returns an info about block by its number
+func (c *Cache) BlockInfoByNumber(ctx context.Context, num entities.BlockNumber) (*entities.Block, error) {
+	n, err := num.ToInt()
+	if err != nil {
+		// Block tags are never cached
+		return c.NodeClient.BlockInfoByNumber(ctx, num)
+	}
+
+	if block, ok := c.block(n.Uint64()); ok {
+		return block, nil
+	}
+
+	block, err := c.NodeClient.BlockInfoByNumber(ctx, num)
+	if err != nil {
+		return nil, err
+	}
+
+	c.storeIfFinal(ctx, block)
+
+	return block, nil
+}
+
+// BlocksByNumbers returns blocks info by their numbers. Only the blocks
+// missing in the store are requested with a batch request
+func (c *Cache) BlocksByNumbers(ctx context.Context, nums []entities.BlockNumber) ([]*entities.Block, error) {
+	out := make([]*entities.Block, len(nums))
+
+	var (
+		missing    []entities.BlockNumber
+		missingIdx []int
+	)
+
+	for i, num := range nums {
+		if n, err := num.ToInt(); err == nil {
+			if block, ok := c.block(n.Uint64()); ok {
+				out[i] = block
+
+				continue
+			}
+		}
+
+		missing = append(missing, num)
+		missingIdx = append(missingIdx, i)
+	}
+
+	if len(missing) == 0 {
+		return out, nil
+	}
+
+	fetched, err := c.NodeClient.BlocksByNumbers(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, block := range fetched {
+		out[missingIdx[i]] = block
+
+		c.storeIfFinal(ctx, block)
+	}
+
+	return out, nil
+}
+
+// BlockReceipts returns receipts of all the transactions included into the block
+func (c *Cache) BlockReceipts(ctx context.Context, num entities.BlockNumber) ([]*entities.Receipt, error) {
+	n, err := num.ToInt()
+	if err != nil {
+		return c.NodeClient.BlockReceipts(ctx, num)
+	}
+
+	if receipts, ok := c.receipts(n.Uint64()); ok {
+		return receipts, nil
+	}
+
+	receipts, err := c.NodeClient.BlockReceipts(ctx, num)
+	if err != nil {
+		return nil, err
+	}
+
+	if final, ok := c.isFinal(ctx, n.Uint64()); ok && final {
+		if err := c.putReceipts(n.Uint64(), receipts); err != nil {
+			c.log.Warn("error store receipts", logger.Err(err), slog.Uint64("block number", n.Uint64()))
+		}
+	}
+
+	return receipts, nil
+}
+
+// storeIfFinal stores the block if it is below the finalized height
+func (c *Cache) storeIfFinal(ctx context.Context, block *entities.Block) {
+	final, ok := c.isFinal(ctx, block.Number.Uint64())
+	if !ok || !final {
+		return
+	}
+
+	if err := c.putBlock(block); err != nil {
+		c.log.Warn("error store block", logger.Err(err), slog.Uint64("block number", block.Number.Uint64()))
+	}
+}
+
+// isFinal reports whether the block is final. The second value is false
+// if the finalized height can not be determined
+func (c *Cache) isFinal(ctx context.Context, num uint64) (bool, bool) {
+	finalized, err := c.finalizedHeight(ctx)
+	if err != nil {
+		c.log.Warn("error fetch finalized height", logger.Err(err))
+
+		return false, false
+	}
+
+	return num <= finalized, true
+}
+
+// finalizedHeight returns the finalized block number. The finalized tag is
+// used if supported, otherwise the head minus the finality depth.
+// The value is cached for a short period. The node is called without
+// holding the lock, so lookups do not wait for a slow provider
+func (c *Cache) finalizedHeight(ctx context.Context) (uint64, error) {
+	c.mu.Lock()
+	finalized, expiry := c.finalized, c.finalizedExpiry
+	c.mu.Unlock()
+
+	if time.Now().Before(expiry) {
+		return finalized, nil
+	}
+
+	finalized, err := c.fetchFinalizedHeight(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// A concurrent refresh may have stored a higher height
+	c.finalized = max(c.finalized, finalized)
+	c.finalizedExpiry = time.Now().Add(finalizedTTL)
+
+	return c.finalized, nil
+}
+
+// fetchFinalizedHeight fetches the finalized block number from the node
+func (c *Cache) fetchFinalizedHeight(ctx context.Context) (uint64, error) {
+	header, err := c.NodeClient.BlockHeaderByNumber(ctx, entities.BlockTagFinalized)
+	if err == nil {
+		return header.Number.Uint64(), nil
+	}
+
+	head, err := c.NodeClient.LastBlockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := head.ToInt()
+	if err != nil {
+		return 0, err
+	}
+
+	if n.Uint64() < c.finalityDepth {
+		return 0, fmt.Errorf("error chain is shorter than finality depth")
+	}
+
+	return n.Uint64() - c.finalityDepth, nil
+}
+
+// block returns a stored block by its number
+func (c *Cache) block(num uint64) (*entities.Block, bool) {
+	var data []byte
+
+	err := c.db.View(func(tx *bolt.Tx) error {
+		hash := tx.Bucket(numbersBucket).Get(numberKey(num))
+		if hash == nil {
+			return nil
+		}
+
+		// Value is valid only during the transaction
+		data = append(data, tx.Bucket(blocksBucket).Get(hash)...)
+
+		return nil
+	})
+	if err != nil || len(data) == 0 {
+		return nil, false
+	}
+
+	block := new(entities.Block)
+
+	if err := json.Unmarshal(data, block); err != nil {
+		c.log.Warn("error decode stored block", logger.Err(err), slog.Uint64("block number", num))
+
+		return nil, false
+	}
+
+	return block, true
+}
+
+// receipts returns stored receipts of a block by its number
+func (c *Cache) receipts(num uint64) ([]*entities.Receipt, bool) {
+	var data []byte
+
+	err := c.db.View(func(tx *bolt.Tx) error {
+		hash := tx.Bucket(numbersBucket).Get(numberKey(num))
+		if hash == nil {
+			return nil
+		}
+
+		data = append(data, tx.Bucket(receiptsBucket).Get(hash)...)
+
+		return nil
+	})
+	if err != nil || len(data) == 0 {
+		return nil, false
+	}
+
+	var receipts []*entities.Receipt
+
+	if err := json.Unmarshal(data, &receipts); err != nil {
+		c.log.Warn("error decode stored receipts", logger.Err(err), slog.Uint64("block number", num))
+
+		return nil, false
+	}
+
+	return receipts, true
+}
+
+// putBlock stores the block and evicts the lowest blocks if the store exceeds its limits
+func (c *Cache) putBlock(block *entities.Block) error {
+	data, err := json.Marshal(block)
+	if err != nil {
+		return fmt.Errorf("error encode block. %w", err)
+	}
+
+	return c.db.Update(func(tx *bolt.Tx) error {
+		numbers := tx.Bucket(numbersBucket)
+		blocks := tx.Bucket(blocksBucket)
+		key := numberKey(block.Number.Uint64())
+
+		if numbers.Get(key) != nil {
+			return nil
+		}
+
+		if err := numbers.Put(key, block.Hash[:]); err != nil {
+			return err
+		}
+
+		if err := blocks.Put(block.Hash[:], data); err != nil {
+			return err
+		}
+
+		meta := tx.Bucket(metaBucket)
+
+		if err := addStat(meta, countKey, 1); err != nil {
+			return err
+		}
+
+		if err := addStat(meta, bytesKey, int64(len(data))); err != nil {
+			return err
+		}
+
+		return c.evict(tx)
+	})
+}
+
+// putReceipts stores receipts of a stored block
+func (c *Cache) putReceipts(num uint64, receipts []*entities.Receipt) error {
+	data, err := json.Marshal(receipts)
+	if err != nil {
+		return fmt.Errorf("error encode receipts. %w", err)
+	}
+
+	return c.db.Update(func(tx *bolt.Tx) error {
+		hash := tx.Bucket(numbersBucket).Get(numberKey(num))
+		if hash == nil {
+			// Receipts are stored only along with their block
+			return nil
+		}
+
+		bucket := tx.Bucket(receiptsBucket)
+
+		if bucket.Get(hash) != nil {
+			return nil
+		}
+
+		if err := bucket.Put(hash, data); err != nil {
+			return err
+		}
+
+		if err := addStat(tx.Bucket(metaBucket), bytesKey, int64(len(data))); err != nil {
+			return err
+		}
+
+		return c.evict(tx)
+	})
+}
+
+// evict removes the lowest blocks until the store fits its limits
+func (c *Cache) evict(tx *bolt.Tx) error {
+	var (
+		meta   = tx.Bucket(metaBucket)
+		cursor = tx.Bucket(numbersBucket).Cursor()
+	)
+
+	for key, hash := cursor.First(); key != nil; key, hash = cursor.First() {
+		count, size := stat(meta, countKey), stat(meta, bytesKey)
+		if count <= c.maxBlocks && (c.maxBytes == 0 || size <= c.maxBytes) {
+			return nil
+		}
+
+		if err := deleteBlock(tx, key, hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// InvalidateBlocks drops stored blocks at the heights, e.g. orphaned by
+// a chain reorganization
+func (c *Cache) InvalidateBlocks(_ context.Context, nums []uint64) error {
+	var dropped []uint64
+
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		numbers := tx.Bucket(numbersBucket)
+
+		for _, num := range nums {
+			key := numberKey(num)
+
+			hash := numbers.Get(key)
+			if hash == nil {
+				continue
+			}
+
+			if err := deleteBlock(tx, key, hash); err != nil {
+				return err
+			}
+
+			dropped = append(dropped, num)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error invalidate blocks. %w", err)
+	}
+
+	if len(dropped) > 0 {
+		c.log.Warn("stored blocks invalidated", slog.Any("block numbers", dropped))
+	}
+
+	return nil
+}
+
+// deleteBlock deletes a stored block with its receipts and updates the store statistics
+func deleteBlock(tx *bolt.Tx, key, hash []byte) error {
+	var (
+		meta     = tx.Bucket(metaBucket)
+		blocks   = tx.Bucket(blocksBucket)
+		receipts = tx.Bucket(receiptsBucket)
+		// Value is valid only during the transaction, so copy it before deletion
+		blockHash = append([]byte(nil), hash...)
+		freed     = len(blocks.Get(blockHash)) + len(receipts.Get(blockHash))
+	)
+
+	if err := tx.Bucket(numbersBucket).Delete(key); err != nil {
+		return err
+	}
+
+	if err := blocks.Delete(blockHash); err != nil {
+		return err
+	}
+
+	if err := receipts.Delete(blockHash); err != nil {
+		return err
+	}
+
+	if err := addStat(meta, countKey, -1); err != nil {
+		return err
+	}
+
+	return addStat(meta, bytesKey, -int64(freed))
+}
+
+// numberKey returns a store key of a block number. Big endian keys
+// keep blocks sorted by number
+func numberKey(num uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, num)
+}
+
+// stat returns a store statistic value
+func stat(meta *bolt.Bucket, key []byte) uint64 {
+	v := meta.Get(key)
+	if len(v) != 8 {
+		return 0
+	}
+
+	return binary.BigEndian.Uint64(v)
+}
+
+// addStat adds delta to a store statistic value
+func addStat(meta *bolt.Bucket, key []byte, delta int64) error {
+	v := int64(stat(meta, key)) + delta
+	if v < 0 {
+		v = 0
+	}
+
+	return meta.Put(key, binary.BigEndian.AppendUint64(nil, uint64(v)))
+}
@@ -0,0 +1,144 @@
+package blockcache
+
+import (
+	"context"
+	"log/slog"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+	"github.com/optclblast/blk/internal/usecase"
+)
+
+// nodeClientMock is a usecase.NodeClient mock with a chain of
+// blocks [0, head], where blocks up to finalized are final
+type nodeClientMock struct {
+	usecase.NodeClient
+	head      int64
+	finalized int64
+	calls     map[uint64]int
+}
+
+func (m *nodeClientMock) newBlock(n uint64) *entities.Block {
+	return &entities.Block{
+		Number:   new(big.Int).SetUint64(n),
+		Hash:     entities.Hash{24: 0xb, 31: byte(n)},
+		GasLimit: big.NewInt(30_000_000),
+		GasUsed:  big.NewInt(21_000),
+		// Zero time is before the unix epoch
+		Timestamp: time.Unix(int64(n)*12, 0),
+		Transactions: []*entities.Transaction{
+			{
+				Hash:  entities.Hash{24: 0xa, 31: byte(n)},
+				From:  entities.Address{19: 0xa},
+				To:    &entities.Address{19: 0xb},
+				Value: big.NewInt(int64(n)),
+				Gas:   big.NewInt(21_000),
+				Nonce: big.NewInt(0),
+			},
+		},
+	}
+}
+
+func (m *nodeClientMock) BlockInfoByNumber(_ context.Context, num entities.BlockNumber) (*entities.Block, error) {
+	n, err := num.ToInt()
+	if err != nil {
+		return nil, err
+	}
+
+	m.calls[n.Uint64()]++
+
+	return m.newBlock(n.Uint64()), nil
+}
+
+func (m *nodeClientMock) BlocksByNumbers(
+	ctx context.Context,
+	nums []entities.BlockNumber,
+) ([]*entities.Block, error) {
+	out := make([]*entities.Block, len(nums))
+
+	for i, num := range nums {
+		block, err := m.BlockInfoByNumber(ctx, num)
+		if err != nil {
+			return nil, err
+		}
+
+		out[i] = block
+	}
+
+	return out, nil
+}
+
+func (m *nodeClientMock) BlockHeaderByNumber(context.Context, entities.BlockNumber) (*entities.Block, error) {
+	return &entities.Block{Number: big.NewInt(m.finalized)}, nil
+}
+
+func TestCache(t *testing.T) {
+	mock := &nodeClientMock{head: 20, finalized: 15, calls: make(map[uint64]int)}
+
+	c, err := New(
+		slog.Default(),
+		mock,
+		filepath.Join(t.TempDir(), "blocks.db"),
+		MaxBlocks(3),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer c.Close()
+
+	nums := []entities.BlockNumber{"0xa", "0xb", "0xc", "0xd", "0x10"}
+
+	for i := 0; i < 2; i++ {
+		blocks, err := c.BlocksByNumbers(context.TODO(), nums)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for j, block := range blocks {
+			expected, _ := nums[j].ToInt()
+
+			if block.Number.Cmp(expected) != 0 || block.Transactions[0].Value.Cmp(expected) != 0 {
+				t.Fatalf("invalid block: %s | Expected: %s", block.Number, expected)
+			}
+		}
+	}
+
+	// Blocks 11, 12 and 13 are final and fit the store. Block 10 is evicted,
+	// block 16 is not final
+	expectedCalls := map[uint64]int{10: 2, 11: 1, 12: 1, 13: 1, 16: 2}
+
+	for n, calls := range expectedCalls {
+		if mock.calls[n] != calls {
+			t.Fatalf("invalid calls of block %d: %d | Expected: %d", n, mock.calls[n], calls)
+		}
+	}
+
+	if _, err := c.BlockInfoByNumber(context.TODO(), "0xc"); err != nil {
+		t.Fatal(err)
+	}
+
+	if mock.calls[12] != 1 {
+		t.Fatalf("invalid calls of block 12: %d | Expected: 1", mock.calls[12])
+	}
+
+	// Invalidated blocks are fetched from the node again
+	if err := c.InvalidateBlocks(context.TODO(), []uint64{12, 13}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.BlocksByNumbers(context.TODO(), []entities.BlockNumber{"0xb", "0xc", "0xd"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedCalls = map[uint64]int{11: 1, 12: 2, 13: 2}
+
+	for n, calls := range expectedCalls {
+		if mock.calls[n] != calls {
+			t.Fatalf("invalid calls of block %d after invalidation: %d | Expected: %d", n, mock.calls[n], calls)
+		}
+	}
+}
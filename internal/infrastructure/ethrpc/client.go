@@ -0,0 +1,441 @@
+// ethrpc package contains a generic Ethereum JSON rpc client
+// that works with any node provider endpoint
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+	"github.com/optclblast/blk/internal/usecase"
+	"github.com/ybbus/jsonrpc/v3"
+	"golang.org/x/time/rate"
+)
+
+// JSON rpc client
+type Client struct {
+	log      *slog.Logger
+	endpoint string
+	cc       jsonrpc.RPCClient
+	limiter  *rate.Limiter
+}
+
+// NewClient returns a new JSON rpc client for the endpoint
+func NewClient(
+	log *slog.Logger,
+	endpoint string,
+	opts ...Option,
+) *Client {
+	c := &Client{
+		log:      log,
+		endpoint: endpoint,
+		cc: jsonrpc.NewClientWithOpts(endpoint, &jsonrpc.RPCClientOpts{
+			HTTPClient: &http.Client{
+				Transport: &rateLimitTransport{next: http.DefaultTransport},
+			},
+		}),
+		limiter: rate.NewLimiter(rate.Inf, 0),
+	}
+
+	// Apply options
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Option configures Client
+type Option func(c *Client)
+
+// RateLimit limits the client to rps requests per second with bursts of
+// up to burst requests. Every request of a batch is counted separately.
+// Zero rps means no limit
+func RateLimit(rps float64, burst int) Option {
+	return func(c *Client) {
+		if rps <= 0 {
+			return
+		}
+
+		c.limiter = rate.NewLimiter(rate.Limit(rps), max(burst, 1))
+	}
+}
+
+// String returns a redacted endpoint the client is connected to
+func (c *Client) String() string {
+	u, err := url.Parse(c.endpoint)
+	if err != nil {
+		return "invalid endpoint"
+	}
+
+	// Access tokens are often passed as a part of the path
+	return u.Scheme + "://" + u.Host
+}
+
+// wait blocks until n requests are allowed by the rate limiter. Requests
+// above the burst size are taken in burst sized chunks. If the requests
+// are not allowed before the ctx deadline, RateLimitError is returned
+func (c *Client) wait(ctx context.Context, n int) error {
+	for n > 0 {
+		chunk := min(n, max(c.limiter.Burst(), 1))
+
+		if err := c.limiter.WaitN(ctx, chunk); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+
+			// The wait would exceed the deadline. Tokens are not taken
+			r := c.limiter.ReserveN(time.Now(), chunk)
+			defer r.Cancel()
+
+			return &RateLimitError{RetryAfter: r.Delay()}
+		}
+
+		n -= chunk
+	}
+
+	return nil
+}
+
+// call performs a JSON rpc call and maps transport errors
+func (c *Client) call(
+	ctx context.Context,
+	method string,
+	params ...any,
+) (*jsonrpc.RPCResponse, error) {
+	if err := c.wait(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	res, err := c.cc.Call(ctx, method, params...)
+	if err != nil {
+		var (
+			rlErr   *RateLimitError
+			httpErr *jsonrpc.HTTPError
+		)
+
+		if errors.As(err, &rlErr) {
+			return nil, rlErr
+		}
+
+		if errors.As(err, &httpErr) {
+			return nil, errors.Join(ErrorProviderUnavailable, err)
+		}
+
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) && !errors.Is(err, context.Canceled) {
+			return nil, errors.Join(ErrorProviderUnavailable, err)
+		}
+
+		return nil, err
+	} else if res.Error != nil {
+		return nil, res.Error
+	}
+
+	return res, nil
+}
+
+// LastBlockNumber returns a last block number
+func (c *Client) LastBlockNumber(ctx context.Context) (entities.BlockNumber, error) {
+	const method = "eth_blockNumber"
+
+	res, err := c.call(ctx, method)
+	if err != nil {
+		return "", fmt.Errorf("error fetch last block number. %w", err)
+	}
+
+	response, err := res.GetString()
+	if err != nil {
+		return "", fmt.Errorf("error parse response. %w", err)
+	}
+
+	c.log.Debug(
+		"last block number",
+		slog.String("method", method),
+		slog.String("resp", response),
+	)
+
+	return entities.BlockNumber(response), nil
+}
+
+// BlockInfoByNumber returns an info about block by its number
+func (c *Client) BlockInfoByNumber(ctx context.Context, num entities.BlockNumber) (*entities.Block, error) {
+	const method = "eth_getBlockByNumber"
+
+	res, err := c.call(ctx, method, num, true)
+	if err != nil {
+		return nil, fmt.Errorf("error fetch block info. %w", err)
+	}
+
+	if res.Result == nil {
+		return nil, fmt.Errorf("error block %s. %w", num, ErrorNotFound)
+	}
+
+	out := new(entities.Block)
+
+	if err := res.GetObject(out); err != nil {
+		return nil, fmt.Errorf("error marshal response body into block object. %w", err)
+	}
+
+	return out, nil
+}
+
+// BlockHeaderByNumber returns an info about block by its number
+// or tag without transactions details
+func (c *Client) BlockHeaderByNumber(ctx context.Context, num entities.BlockNumber) (*entities.Block, error) {
+	const method = "eth_getBlockByNumber"
+
+	res, err := c.call(ctx, method, num, false)
+	if err != nil {
+		return nil, fmt.Errorf("error fetch block header. %w", err)
+	}
+
+	if res.Result == nil {
+		return nil, fmt.Errorf("error block %s. %w", num, ErrorNotFound)
+	}
+
+	out := new(entities.Block)
+
+	if err := res.GetObject(out); err != nil {
+		return nil, fmt.Errorf("error marshal response body into block object. %w", err)
+	}
+
+	return out, nil
+}
+
+// BlocksByNumbers returns blocks info by their numbers using a batch request
+func (c *Client) BlocksByNumbers(ctx context.Context, nums []entities.BlockNumber) ([]*entities.Block, error) {
+	const method = "eth_getBlockByNumber"
+
+	requests := make(jsonrpc.RPCRequests, len(nums))
+	for i, num := range nums {
+		requests[i] = jsonrpc.NewRequestWithID(i, method, num, true)
+	}
+
+	if err := c.wait(ctx, len(nums)); err != nil {
+		return nil, err
+	}
+
+	res, err := c.cc.CallBatch(ctx, requests)
+	if err != nil {
+		return nil, batchError(err)
+	}
+
+	if rejectsBatch(res) {
+		return nil, fmt.Errorf("%w. %s", usecase.ErrorBatchNotSupported, res[0].Error)
+	}
+
+	byID := res.AsMap()
+	out := make([]*entities.Block, len(nums))
+
+	for i, num := range nums {
+		r, ok := byID[i]
+		if !ok {
+			return nil, fmt.Errorf("error block %s is missing in batch response", num)
+		}
+
+		if r.Error != nil {
+			return nil, fmt.Errorf("error fetch block %s info. %w", num, r.Error)
+		}
+
+		if r.Result == nil {
+			return nil, fmt.Errorf("error block %s. %w", num, ErrorNotFound)
+		}
+
+		out[i] = new(entities.Block)
+
+		if err := r.GetObject(out[i]); err != nil {
+			return nil, fmt.Errorf("error marshal response body into block object. %w", err)
+		}
+	}
+
+	c.log.Debug(
+		"blocks by numbers",
+		slog.String("method", method),
+		slog.Int("batch size", len(nums)),
+	)
+
+	return out, nil
+}
+
+// JSON rpc error codes of requests a provider can not handle
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+)
+
+// batchError maps a batch call error. Only responses of providers rejecting
+// batches are ErrorBatchNotSupported, e.g. a single object instead of an array
+// or 400 Bad Request. Authorization errors and throttling are passed through
+func batchError(err error) error {
+	var (
+		rlErr   *RateLimitError
+		httpErr *jsonrpc.HTTPError
+		typeErr *json.UnmarshalTypeError
+		urlErr  *url.Error
+	)
+
+	switch {
+	case errors.As(err, &rlErr):
+		return rlErr
+	case errors.As(err, &httpErr) && httpErr.Code >= http.StatusInternalServerError:
+		return errors.Join(ErrorProviderUnavailable, err)
+	case errors.As(err, &httpErr) && httpErr.Code == http.StatusBadRequest:
+		return errors.Join(usecase.ErrorBatchNotSupported, err)
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("error fetch blocks info. %w", err)
+	case errors.As(err, &urlErr):
+		return errors.Join(ErrorProviderUnavailable, err)
+	case errors.As(err, &typeErr):
+		// A single error object responded to the whole batch
+		return errors.Join(usecase.ErrorBatchNotSupported, err)
+	default:
+		return fmt.Errorf("error fetch blocks info. %w", err)
+	}
+}
+
+// rejectsBatch reports whether the responses reject the batch as a whole,
+// i.e. all of them are parse, invalid request or method not found errors
+func rejectsBatch(res jsonrpc.RPCResponses) bool {
+	if len(res) == 0 {
+		return false
+	}
+
+	for _, r := range res {
+		if r.Error == nil {
+			return false
+		}
+
+		switch r.Error.Code {
+		case codeParseError, codeInvalidRequest, codeMethodNotFound:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// BlockReceipts returns receipts of all the transactions included into the block
+func (c *Client) BlockReceipts(ctx context.Context, num entities.BlockNumber) ([]*entities.Receipt, error) {
+	const method = "eth_getBlockReceipts"
+
+	res, err := c.call(ctx, method, num)
+	if err != nil {
+		return nil, fmt.Errorf("error fetch block receipts. %w", err)
+	}
+
+	var out []*entities.Receipt
+
+	if err := res.GetObject(&out); err != nil {
+		return nil, fmt.Errorf("error marshal response body into receipts. %w", err)
+	}
+
+	return out, nil
+}
+
+// BlockTraces returns call traces of all the transactions included into the block
+func (c *Client) BlockTraces(ctx context.Context, num entities.BlockNumber) ([]*entities.TxTrace, error) {
+	const method = "debug_traceBlockByNumber"
+
+	res, err := c.call(ctx, method, num, map[string]string{"tracer": "callTracer"})
+	if err != nil {
+		return nil, fmt.Errorf("error fetch block traces. %w", err)
+	}
+
+	var out []*entities.TxTrace
+
+	if err := res.GetObject(&out); err != nil {
+		return nil, fmt.Errorf("error marshal response body into traces. %w", err)
+	}
+
+	return out, nil
+}
+
+// BalanceAt returns the address balance in wei at the block
+func (c *Client) BalanceAt(ctx context.Context, address entities.Address, num entities.BlockNumber) (*big.Int, error) {
+	const method = "eth_getBalance"
+
+	res, err := c.call(ctx, method, address, num)
+	if err != nil {
+		return nil, fmt.Errorf("error fetch balance. %w", err)
+	}
+
+	response, err := res.GetString()
+	if err != nil {
+		return nil, fmt.Errorf("error parse response. %w", err)
+	}
+
+	balance, err := entities.ParseQuantity(response)
+	if err != nil {
+		return nil, fmt.Errorf("error parse balance. %w", err)
+	}
+
+	return balance, nil
+}
+
+// CodeAt returns the hex encoded code deployed at the address
+func (c *Client) CodeAt(ctx context.Context, address entities.Address, num entities.BlockNumber) (string, error) {
+	const method = "eth_getCode"
+
+	res, err := c.call(ctx, method, address, num)
+	if err != nil {
+		return "", fmt.Errorf("error fetch code. %w", err)
+	}
+
+	response, err := res.GetString()
+	if err != nil {
+		return "", fmt.Errorf("error parse response. %w", err)
+	}
+
+	return response, nil
+}
+
+// Logs returns event logs matching the filter
+func (c *Client) Logs(ctx context.Context, filter entities.LogFilter) ([]*entities.Log, error) {
+	const method = "eth_getLogs"
+
+	res, err := c.call(ctx, method, filter)
+	if err != nil {
+		return nil, fmt.Errorf("error fetch logs. %w", err)
+	}
+
+	var out []*entities.Log
+
+	if err := res.GetObject(&out); err != nil {
+		return nil, fmt.Errorf("error marshal response body into logs. %w", err)
+	}
+
+	return out, nil
+}
+
+// TransactionReceipt returns a transaction receipt by the transaction hash
+func (c *Client) TransactionReceipt(ctx context.Context, hash entities.Hash) (*entities.Receipt, error) {
+	const method = "eth_getTransactionReceipt"
+
+	// A single array param would be sent as the params array itself
+	res, err := c.call(ctx, method, hash.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("error fetch transaction receipt. %w", err)
+	}
+
+	if res.Result == nil {
+		return nil, fmt.Errorf("error receipt of %s. %w", hash, ErrorNotFound)
+	}
+
+	out := new(entities.Receipt)
+
+	if err := res.GetObject(out); err != nil {
+		return nil, fmt.Errorf("error marshal response body into receipt object. %w", err)
+	}
+
+	return out, nil
+}
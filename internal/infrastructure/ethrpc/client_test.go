@@ -0,0 +1,340 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+	"github.com/optclblast/blk/internal/usecase"
+)
+
+// rpcRequest is a JSON rpc request received by the test server
+type rpcRequest struct {
+	ID     int               `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// newTestServer returns a JSON rpc test server responding with the handler
+// status code, headers and body. Requests of a batch are passed at once
+func newTestServer(
+	t *testing.T,
+	handler func(requests []rpcRequest) (int, http.Header, string),
+) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("error read request. %s", err)
+			return
+		}
+
+		var requests []rpcRequest
+
+		if err := json.Unmarshal(body, &requests); err != nil {
+			requests = make([]rpcRequest, 1)
+
+			if err := json.Unmarshal(body, &requests[0]); err != nil {
+				t.Errorf("error decode request. %s", err)
+				return
+			}
+		}
+
+		status, header, out := handler(requests)
+
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+
+		w.WriteHeader(status)
+
+		_, _ = io.WriteString(w, out)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestCall(t *testing.T) {
+	for _, tc := range callTests {
+		t.Run(tc.Title, func(t *testing.T) {
+			srv := newTestServer(t, func([]rpcRequest) (int, http.Header, string) {
+				return tc.Status, tc.Header, tc.Body
+			})
+
+			num, err := NewClient(slog.Default(), srv.URL).LastBlockNumber(context.TODO())
+
+			if !tc.MustFail {
+				if err != nil || num != tc.Expected {
+					t.Fatalf("invalid result: %s, %v | Expected: %s", num, err, tc.Expected)
+				}
+
+				return
+			}
+
+			if err == nil || (tc.ExpectedErr != nil && !errors.Is(err, tc.ExpectedErr)) {
+				t.Fatalf("invalid error: %v | Expected: %s", err, tc.ExpectedErr)
+			}
+
+			if IsTemporary(err) != tc.Temporary {
+				t.Fatalf("invalid temporary flag of %v | Expected: %v", err, tc.Temporary)
+			}
+
+			if d := RetryAfter(err); d != tc.ExpectedRetryAfter {
+				t.Fatalf("invalid retry after: %s | Expected: %s", d, tc.ExpectedRetryAfter)
+			}
+		})
+	}
+}
+
+type CallTestCase struct {
+	Title              string
+	Status             int
+	Header             http.Header
+	Body               string
+	Expected           entities.BlockNumber
+	ExpectedErr        error
+	ExpectedRetryAfter time.Duration
+	Temporary          bool
+	MustFail           bool
+}
+
+var callTests = []CallTestCase{
+	{
+		Title:    "Result",
+		Status:   http.StatusOK,
+		Body:     `{"jsonrpc": "2.0", "id": 0, "result": "0x10"}`,
+		Expected: "0x10",
+	},
+	{
+		Title:              "Rate limited",
+		Status:             http.StatusTooManyRequests,
+		Header:             http.Header{"Retry-After": {"2"}},
+		ExpectedErr:        ErrorRateLimitExceeded,
+		ExpectedRetryAfter: 2 * time.Second,
+		Temporary:          true,
+		MustFail:           true,
+	},
+	{
+		Title:       "Server error",
+		Status:      http.StatusBadGateway,
+		Body:        "bad gateway",
+		ExpectedErr: ErrorProviderUnavailable,
+		Temporary:   true,
+		MustFail:    true,
+	},
+	{
+		Title:    "JSON rpc error",
+		Status:   http.StatusOK,
+		Body:     `{"jsonrpc": "2.0", "id": 0, "error": {"code": -32000, "message": "header not found"}}`,
+		MustFail: true,
+	},
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	expected := map[string]time.Duration{
+		"":                              0,
+		"3":                             3 * time.Second,
+		"-1":                            0,
+		"soon":                          0,
+		"Sat, 01 Jun 2024 12:00:05 GMT": 5 * time.Second,
+		"Sat, 01 Jun 2024 11:59:00 GMT": 0,
+	}
+
+	for v, e := range expected {
+		if d := parseRetryAfter(v, now); d != e {
+			t.Fatalf("invalid delay of %q: %s | Expected: %s", v, d, e)
+		}
+	}
+}
+
+func TestBlocksByNumbers(t *testing.T) {
+	srv := newBlocksServer(t)
+
+	nums := []entities.BlockNumber{"0xa", "0xc", "0xb"}
+
+	blocks, err := NewClient(slog.Default(), srv.URL).BlocksByNumbers(context.TODO(), nums)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, b := range blocks {
+		if entities.NewBlockNumber(b.Number) != nums[i] {
+			t.Fatalf("invalid block #%d: %s | Expected: %s", i, entities.NewBlockNumber(b.Number), nums[i])
+		}
+	}
+}
+
+func TestBatchErrors(t *testing.T) {
+	for _, tc := range batchErrorTests {
+		t.Run(tc.Title, func(t *testing.T) {
+			srv := newTestServer(t, func([]rpcRequest) (int, http.Header, string) {
+				return tc.Status, nil, tc.Body
+			})
+
+			_, err := NewClient(slog.Default(), srv.URL).BlocksByNumbers(
+				context.TODO(),
+				[]entities.BlockNumber{"0xa", "0xb"},
+			)
+			if err == nil {
+				t.Fatal("call must fail")
+			}
+
+			if errors.Is(err, usecase.ErrorBatchNotSupported) != tc.NotSupported {
+				t.Fatalf("invalid error: %v | Batch not supported: %v", err, tc.NotSupported)
+			}
+
+			if tc.ExpectedErr != nil && !errors.Is(err, tc.ExpectedErr) {
+				t.Fatalf("invalid error: %v | Expected: %s", err, tc.ExpectedErr)
+			}
+		})
+	}
+}
+
+type BatchErrorTestCase struct {
+	Title        string
+	Status       int
+	Body         string
+	ExpectedErr  error
+	NotSupported bool
+}
+
+var batchErrorTests = []BatchErrorTestCase{
+	{
+		Title:        "Single error object",
+		Status:       http.StatusOK,
+		Body:         `{"jsonrpc": "2.0", "id": null, "error": {"code": -32600, "message": "batch is not supported"}}`,
+		NotSupported: true,
+	},
+	{
+		Title:        "Method not found for the whole batch",
+		Status:       http.StatusOK,
+		Body:         `[{"jsonrpc": "2.0", "id": null, "error": {"code": -32601, "message": "method not found"}}]`,
+		NotSupported: true,
+	},
+	{
+		Title:        "Bad request",
+		Status:       http.StatusBadRequest,
+		Body:         "batch requests are disabled",
+		NotSupported: true,
+	},
+	{
+		Title:  "Unauthorized",
+		Status: http.StatusUnauthorized,
+		Body:   "invalid api key",
+	},
+	{
+		Title:  "Forbidden",
+		Status: http.StatusForbidden,
+		Body:   `{"jsonrpc": "2.0", "id": null, "error": {"code": -32600, "message": "access denied"}}`,
+	},
+	{
+		Title:       "Rate limited",
+		Status:      http.StatusTooManyRequests,
+		ExpectedErr: ErrorRateLimitExceeded,
+	},
+	{
+		Title:       "Server error",
+		Status:      http.StatusServiceUnavailable,
+		ExpectedErr: ErrorProviderUnavailable,
+	},
+	{
+		Title:  "Not a JSON body",
+		Status: http.StatusOK,
+		Body:   "<html>maintenance</html>",
+	},
+	{
+		Title:  "Block error",
+		Status: http.StatusOK,
+		Body: `[{"jsonrpc": "2.0", "id": 0, "error": {"code": -32000, "message": "header not found"}},
+			{"jsonrpc": "2.0", "id": 1, "error": {"code": -32000, "message": "header not found"}}]`,
+	},
+}
+
+// newBlocksServer returns a test server responding to batches of block requests
+func newBlocksServer(t *testing.T) *httptest.Server {
+	return newTestServer(t, func(requests []rpcRequest) (int, http.Header, string) {
+		responses := make([]json.RawMessage, 0, len(requests))
+
+		// Responses come in the reverse order
+		for i := len(requests) - 1; i >= 0; i-- {
+			r := requests[i]
+			responses = append(responses, json.RawMessage(fmt.Sprintf(
+				`{"jsonrpc": "2.0", "id": %d, "result": {
+					"number": %s, "gasLimit": "0x1", "gasUsed": "0x0", "timestamp": "0x0"
+				}}`,
+				r.ID, r.Params[0],
+			)))
+		}
+
+		out, err := json.Marshal(responses)
+		if err != nil {
+			t.Errorf("error encode responses. %s", err)
+		}
+
+		return http.StatusOK, nil, string(out)
+	})
+}
+
+func TestBatchRateLimit(t *testing.T) {
+	srv := newBlocksServer(t)
+
+	// Bursts of 2 requests, then a request every 10ms
+	c := NewClient(slog.Default(), srv.URL, RateLimit(100, 2))
+
+	nums := make([]entities.BlockNumber, 12)
+	for i := range nums {
+		nums[i] = entities.NewBlockNumber(big.NewInt(int64(i)))
+	}
+
+	start := time.Now()
+
+	if _, err := c.BlocksByNumbers(context.TODO(), nums); err != nil {
+		t.Fatal(err)
+	}
+
+	// 10 requests above the burst take at least 100ms
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("every batch request must be rate limited: %s | Expected at least: 100ms", elapsed)
+	}
+}
+
+func TestRateLimitDeadline(t *testing.T) {
+	srv := newBlocksServer(t)
+
+	// A request per second, so the second request can not be sent within the call timeout
+	c := NewClient(slog.Default(), srv.URL, RateLimit(1, 1))
+
+	blockWithTimeout := func(n int64) error {
+		ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+		defer cancel()
+
+		_, err := c.BlocksByNumbers(ctx, []entities.BlockNumber{entities.NewBlockNumber(big.NewInt(n))})
+
+		return err
+	}
+
+	if err := blockWithTimeout(0); err != nil {
+		t.Fatal(err)
+	}
+
+	err := blockWithTimeout(1)
+	if !errors.Is(err, ErrorRateLimitExceeded) || !IsTemporary(err) {
+		t.Fatalf("invalid error: %v | Expected: %s", err, ErrorRateLimitExceeded)
+	}
+
+	if d := RetryAfter(err); d <= 0 || d > time.Second {
+		t.Fatalf("invalid retry after: %s | Expected: (0s, 1s]", d)
+	}
+}
@@ -0,0 +1,68 @@
+package ethrpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+var (
+	// ErrorRateLimitExceeded is thrown when
+	// the number of requests has exceeded the allowed limit
+	ErrorRateLimitExceeded = errors.New("api rate limit exceeded")
+
+	// ErrorProviderUnavailable is thrown when the node provider
+	// can not be reached or responds with a server error
+	ErrorProviderUnavailable = errors.New("node provider unavailable")
+
+	// ErrorNotFound is thrown when the requested object does not exist
+	ErrorNotFound = errors.New("not found")
+)
+
+// RateLimitError is thrown when the node provider responds with
+// 429 Too Many Requests. RateLimitError is ErrorRateLimitExceeded
+type RateLimitError struct {
+	// RetryAfter is a delay requested by the provider. Zero if unknown
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter == 0 {
+		return ErrorRateLimitExceeded.Error()
+	}
+
+	return ErrorRateLimitExceeded.Error() + ". retry after " + e.RetryAfter.String()
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrorRateLimitExceeded
+}
+
+// IsTemporary reports whether err is a transient node provider
+// error, so the call is worth retrying later or with another provider
+func IsTemporary(err error) bool {
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, ErrorRateLimitExceeded),
+		errors.Is(err, ErrorProviderUnavailable),
+		errors.Is(err, context.DeadlineExceeded):
+		return true
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return true
+	default:
+		return false
+	}
+}
+
+// RetryAfter returns a delay requested by the node provider
+// if err is a RateLimitError
+func RetryAfter(err error) time.Duration {
+	var rlErr *RateLimitError
+	if errors.As(err, &rlErr) {
+		return rlErr.RetryAfter
+	}
+
+	return 0
+}
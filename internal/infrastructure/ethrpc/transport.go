@@ -0,0 +1,44 @@
+package ethrpc
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// rateLimitTransport turns 429 Too Many Requests responses into RateLimitError,
+// so the Retry-After header is not lost by the JSON rpc client
+type rateLimitTransport struct {
+	next http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return resp, err
+	}
+
+	resp.Body.Close()
+
+	return nil, &RateLimitError{
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter parses Retry-After header value which is either
+// a number of seconds or an HTTP date
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(v); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
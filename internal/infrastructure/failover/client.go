@@ -0,0 +1,328 @@
+// failover package contains a composite node client that spreads calls
+// over several node providers and fails over between them
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+	"github.com/optclblast/blk/internal/infrastructure/ethrpc"
+	"github.com/optclblast/blk/internal/logger"
+	"github.com/optclblast/blk/internal/usecase"
+)
+
+const (
+	defaultCallTimeout         = 5 * time.Second
+	defaultCooldown            = 30 * time.Second
+	defaultHealthCheckInterval = 15 * time.Second
+)
+
+// Provider is a named node provider
+type Provider struct {
+	Name   string
+	Client usecase.NodeClient
+}
+
+// provider is a node provider with its health state
+type provider struct {
+	Provider
+	// Unix nano time until the provider is considered unhealthy
+	unhealthyUntil atomic.Int64
+	// The provider rejected a batch request
+	batchUnsupported atomic.Bool
+}
+
+func (p *provider) healthy(now time.Time) bool {
+	return p.unhealthyUntil.Load() <= now.UnixNano()
+}
+
+// Client is a composite usecase.NodeClient implementation. Providers are
+// called in priority order. A provider that is rate limited, unavailable or
+// timed out is skipped for a cooldown period and the call is retried with
+// the next one. A provider that rejects batch requests is not sent batches
+// anymore, they are sent to the next providers.
+type Client struct {
+	log                 *slog.Logger
+	providers           []*provider
+	callTimeout         time.Duration
+	cooldown            time.Duration
+	healthCheckInterval time.Duration
+}
+
+// New returns a new failover Client. Providers are listed from the
+// highest priority to the lowest
+func New(
+	log *slog.Logger,
+	providers []Provider,
+	opts ...Option,
+) *Client {
+	c := &Client{
+		log:                 log,
+		providers:           make([]*provider, len(providers)),
+		callTimeout:         defaultCallTimeout,
+		cooldown:            defaultCooldown,
+		healthCheckInterval: defaultHealthCheckInterval,
+	}
+
+	for i, p := range providers {
+		c.providers[i] = &provider{Provider: p}
+	}
+
+	// Apply options
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Option configures Client
+type Option func(c *Client)
+
+// CallTimeout sets a specific timeout of a single provider call
+func CallTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.callTimeout = timeout
+	}
+}
+
+// Cooldown sets a specific period a failed provider is skipped for
+func Cooldown(cooldown time.Duration) Option {
+	return func(c *Client) {
+		c.cooldown = cooldown
+	}
+}
+
+// HealthCheckInterval sets a specific providers health check interval
+func HealthCheckInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		c.healthCheckInterval = interval
+	}
+}
+
+// Start runs providers health checks in background until ctx is done
+func (c *Client) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(c.healthCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.healthCheck(ctx)
+			}
+		}
+	}()
+}
+
+// healthCheck probes every provider with a cheap call
+func (c *Client) healthCheck(ctx context.Context) {
+	for _, p := range c.providers {
+		callCtx, cancel := context.WithTimeout(ctx, c.callTimeout)
+
+		_, err := p.Client.LastBlockNumber(callCtx)
+
+		cancel()
+
+		switch {
+		case err == nil:
+			if !p.healthy(time.Now()) {
+				c.log.Info("provider is healthy again", slog.String("provider", p.Name))
+			}
+
+			p.unhealthyUntil.Store(0)
+		case ctx.Err() != nil:
+			return
+		default:
+			c.markUnhealthy(p, err)
+		}
+	}
+}
+
+func (c *Client) markUnhealthy(p *provider, err error) {
+	p.unhealthyUntil.Store(time.Now().Add(c.cooldown).UnixNano())
+
+	c.log.Warn(
+		"provider marked unhealthy",
+		slog.String("provider", p.Name),
+		slog.Duration("cooldown", c.cooldown),
+		logger.Err(err),
+	)
+}
+
+// candidates returns healthy providers in priority order. If there are no
+// healthy providers left, all of them are returned
+func (c *Client) candidates() []*provider {
+	now := time.Now()
+	out := make([]*provider, 0, len(c.providers))
+
+	for _, p := range c.providers {
+		if p.healthy(now) {
+			out = append(out, p)
+		}
+	}
+
+	if len(out) == 0 {
+		return c.providers
+	}
+
+	return out
+}
+
+// call calls fn with the candidates one by one until it succeeds
+// or fails with an error that is not worth failing over
+func call[T any](
+	ctx context.Context,
+	c *Client,
+	fn func(ctx context.Context, client usecase.NodeClient) (T, error),
+) (T, error) {
+	return callProviders(ctx, c, c.candidates(), fn)
+}
+
+// callProviders calls fn with providers one by one until it succeeds
+// or fails with an error that is not worth failing over
+func callProviders[T any](
+	ctx context.Context,
+	c *Client,
+	providers []*provider,
+	fn func(ctx context.Context, client usecase.NodeClient) (T, error),
+) (T, error) {
+	var (
+		out  T
+		errs []error
+	)
+
+	for _, p := range providers {
+		callCtx, cancel := context.WithTimeout(ctx, c.callTimeout)
+
+		res, err := fn(callCtx, p.Client)
+
+		cancel()
+
+		if err == nil {
+			return res, nil
+		}
+
+		if ctx.Err() != nil {
+			return out, ctx.Err()
+		}
+
+		// The provider is healthy, but batches are sent to the next ones
+		if errors.Is(err, usecase.ErrorBatchNotSupported) {
+			p.batchUnsupported.Store(true)
+
+			c.log.Info("provider does not support batch requests", slog.String("provider", p.Name))
+
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+
+			continue
+		}
+
+		if !ethrpc.IsTemporary(err) {
+			return out, err
+		}
+
+		c.markUnhealthy(p, err)
+
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+	}
+
+	return out, fmt.Errorf("%w. %w", ErrorAllProvidersFailed, errors.Join(errs...))
+}
+
+// LastBlockNumber returns a last block number
+func (c *Client) LastBlockNumber(ctx context.Context) (entities.BlockNumber, error) {
+	return call(ctx, c, func(ctx context.Context, cc usecase.NodeClient) (entities.BlockNumber, error) {
+		return cc.LastBlockNumber(ctx)
+	})
+}
+
+// BlockInfoByNumber returns an info about block by its number
+func (c *Client) BlockInfoByNumber(ctx context.Context, num entities.BlockNumber) (*entities.Block, error) {
+	return call(ctx, c, func(ctx context.Context, cc usecase.NodeClient) (*entities.Block, error) {
+		return cc.BlockInfoByNumber(ctx, num)
+	})
+}
+
+// BlockHeaderByNumber returns an info about block by its number
+// or tag without transactions details
+func (c *Client) BlockHeaderByNumber(ctx context.Context, num entities.BlockNumber) (*entities.Block, error) {
+	return call(ctx, c, func(ctx context.Context, cc usecase.NodeClient) (*entities.Block, error) {
+		return cc.BlockHeaderByNumber(ctx, num)
+	})
+}
+
+// BlocksByNumbers returns blocks info by their numbers using a batch request.
+// Providers that rejected batch requests are skipped
+func (c *Client) BlocksByNumbers(ctx context.Context, nums []entities.BlockNumber) ([]*entities.Block, error) {
+	var providers []*provider
+
+	for _, p := range c.candidates() {
+		if !p.batchUnsupported.Load() {
+			providers = append(providers, p)
+		}
+	}
+
+	if len(providers) == 0 {
+		return nil, usecase.ErrorBatchNotSupported
+	}
+
+	return callProviders(
+		ctx,
+		c,
+		providers,
+		func(ctx context.Context, cc usecase.NodeClient) ([]*entities.Block, error) {
+			return cc.BlocksByNumbers(ctx, nums)
+		},
+	)
+}
+
+// BlockReceipts returns receipts of all the transactions included into the block
+func (c *Client) BlockReceipts(ctx context.Context, num entities.BlockNumber) ([]*entities.Receipt, error) {
+	return call(ctx, c, func(ctx context.Context, cc usecase.NodeClient) ([]*entities.Receipt, error) {
+		return cc.BlockReceipts(ctx, num)
+	})
+}
+
+// BlockTraces returns call traces of all the transactions included into the block
+func (c *Client) BlockTraces(ctx context.Context, num entities.BlockNumber) ([]*entities.TxTrace, error) {
+	return call(ctx, c, func(ctx context.Context, cc usecase.NodeClient) ([]*entities.TxTrace, error) {
+		return cc.BlockTraces(ctx, num)
+	})
+}
+
+// BalanceAt returns the address balance in wei at the block
+func (c *Client) BalanceAt(ctx context.Context, address entities.Address, num entities.BlockNumber) (*big.Int, error) {
+	return call(ctx, c, func(ctx context.Context, cc usecase.NodeClient) (*big.Int, error) {
+		return cc.BalanceAt(ctx, address, num)
+	})
+}
+
+// CodeAt returns the hex encoded code deployed at the address
+func (c *Client) CodeAt(ctx context.Context, address entities.Address, num entities.BlockNumber) (string, error) {
+	return call(ctx, c, func(ctx context.Context, cc usecase.NodeClient) (string, error) {
+		return cc.CodeAt(ctx, address, num)
+	})
+}
+
+// Logs returns event logs matching the filter
+func (c *Client) Logs(ctx context.Context, filter entities.LogFilter) ([]*entities.Log, error) {
+	return call(ctx, c, func(ctx context.Context, cc usecase.NodeClient) ([]*entities.Log, error) {
+		return cc.Logs(ctx, filter)
+	})
+}
+
+// TransactionReceipt returns a transaction receipt by the transaction hash
+func (c *Client) TransactionReceipt(ctx context.Context, hash entities.Hash) (*entities.Receipt, error) {
+	return call(ctx, c, func(ctx context.Context, cc usecase.NodeClient) (*entities.Receipt, error) {
+		return cc.TransactionReceipt(ctx, hash)
+	})
+}
@@ -0,0 +1,164 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/optclblast/blk/internal/entities"
+	"github.com/optclblast/blk/internal/infrastructure/ethrpc"
+	"github.com/optclblast/blk/internal/usecase"
+)
+
+// nodeClientMock is a usecase.NodeClient mock answering
+// LastBlockNumber with a predefined result
+type nodeClientMock struct {
+	usecase.NodeClient
+	head  entities.BlockNumber
+	err   error
+	calls int
+}
+
+func (m *nodeClientMock) LastBlockNumber(context.Context) (entities.BlockNumber, error) {
+	m.calls++
+
+	return m.head, m.err
+}
+
+// BlocksByNumbers answers with a single block of the head number
+func (m *nodeClientMock) BlocksByNumbers(context.Context, []entities.BlockNumber) ([]*entities.Block, error) {
+	m.calls++
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	n, err := m.head.ToInt()
+	if err != nil {
+		return nil, err
+	}
+
+	return []*entities.Block{{Number: n}}, nil
+}
+
+func TestFailover(t *testing.T) {
+	for _, tc := range tests {
+		t.Run(tc.Title, func(t *testing.T) {
+			primary := &nodeClientMock{head: "0x1", err: tc.PrimaryErr}
+			secondary := &nodeClientMock{head: "0x2"}
+
+			c := New(slog.Default(), []Provider{
+				{Name: "primary", Client: primary},
+				{Name: "secondary", Client: secondary},
+			})
+
+			for i := 0; i < 2; i++ {
+				head, err := c.LastBlockNumber(context.TODO())
+
+				if tc.MustFail {
+					if !errors.Is(err, tc.PrimaryErr) {
+						t.Fatalf("invalid error: %v | Expected: %v", err, tc.PrimaryErr)
+					}
+
+					continue
+				}
+
+				if err != nil {
+					t.Fatalf("error: %s\n", err.Error())
+				}
+
+				if head != tc.ExpectedHead {
+					t.Fatalf("invalid result: %s | Expected: %s", head, tc.ExpectedHead)
+				}
+			}
+
+			if primary.calls != tc.ExpectedPrimaryCalls {
+				t.Fatalf("invalid primary calls: %d | Expected: %d", primary.calls, tc.ExpectedPrimaryCalls)
+			}
+		})
+	}
+}
+
+type TestCase struct {
+	Title                string
+	PrimaryErr           error
+	ExpectedHead         entities.BlockNumber
+	ExpectedPrimaryCalls int
+	MustFail             bool
+}
+
+var tests = []TestCase{
+	{
+		Title:                "Primary is healthy",
+		ExpectedHead:         "0x1",
+		ExpectedPrimaryCalls: 2,
+	},
+	{
+		Title:                "Primary is rate limited. Skipped during cooldown",
+		PrimaryErr:           ethrpc.ErrorRateLimitExceeded,
+		ExpectedHead:         "0x2",
+		ExpectedPrimaryCalls: 1,
+	},
+	{
+		Title:                "Primary timed out",
+		PrimaryErr:           context.DeadlineExceeded,
+		ExpectedHead:         "0x2",
+		ExpectedPrimaryCalls: 1,
+	},
+	{
+		Title:                "Primary returned an RPC error. No failover",
+		PrimaryErr:           errors.New("invalid params"),
+		ExpectedPrimaryCalls: 2,
+		MustFail:             true,
+	},
+}
+
+func TestBatchFailover(t *testing.T) {
+	primary := &nodeClientMock{head: "0x1", err: usecase.ErrorBatchNotSupported}
+	secondary := &nodeClientMock{head: "0x2"}
+
+	c := New(slog.Default(), []Provider{
+		{Name: "primary", Client: primary},
+		{Name: "secondary", Client: secondary},
+	})
+
+	// Batches are sent to the secondary, the primary is asked once
+	for i := 0; i < 2; i++ {
+		blocks, err := c.BlocksByNumbers(context.TODO(), []entities.BlockNumber{"0x0"})
+		if err != nil {
+			t.Fatalf("error: %s\n", err.Error())
+		}
+
+		if blocks[0].Number.Int64() != 2 {
+			t.Fatalf("invalid block: %s | Expected: 2", blocks[0].Number)
+		}
+	}
+
+	if primary.calls != 1 || secondary.calls != 2 {
+		t.Fatalf(
+			"invalid calls: %d primary, %d secondary | Expected: 1 primary, 2 secondary",
+			primary.calls, secondary.calls,
+		)
+	}
+
+	// The primary is still healthy for other calls
+	primary.err = nil
+
+	if head, err := c.LastBlockNumber(context.TODO()); err != nil || head != "0x1" {
+		t.Fatalf("invalid result: %s, %v | Expected: 0x1", head, err)
+	}
+
+	// Without batch providers left batches are not supported
+	secondary.err = usecase.ErrorBatchNotSupported
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.BlocksByNumbers(context.TODO(), nil); !errors.Is(err, usecase.ErrorBatchNotSupported) {
+			t.Fatalf("invalid error: %v | Expected: %s", err, usecase.ErrorBatchNotSupported)
+		}
+	}
+
+	if secondary.calls != 3 {
+		t.Fatalf("invalid secondary calls: %d | Expected: 3", secondary.calls)
+	}
+}
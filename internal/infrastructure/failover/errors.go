@@ -0,0 +1,8 @@
+package failover
+
+import "errors"
+
+var (
+	// ErrorAllProvidersFailed is thrown when every provider failed to serve the call
+	ErrorAllProvidersFailed = errors.New("all node providers failed")
+)
@@ -1,9 +1,9 @@
 package getblock
 
-import "errors"
+import "github.com/optclblast/blk/internal/infrastructure/ethrpc"
 
 var (
 	// ErrorRateLimitExceeded is thrown when
 	// the number of requests has exceeded the allowed limit
-	ErrorRateLimitExceeded = errors.New("api rate limit exceeded")
+	ErrorRateLimitExceeded = ethrpc.ErrorRateLimitExceeded
 )
@@ -0,0 +1,177 @@
+// labels package contains an address labels registry
+// loaded from local CSV and JSON files
+package labels
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+// Registry is a usecase.LabelRegistry implementation. Labels are loaded from
+// CSV files with the address,name,category header and an optional symbol column
+// and JSON files with an array of {"address", "name", "category", "symbol"}
+// objects. Labels of later files override labels of earlier ones. Registry is
+// safe for concurrent use
+type Registry struct {
+	log   *slog.Logger
+	paths []string
+
+	mu sync.RWMutex
+	// map [Address => Label]
+	labels map[entities.Address]*entities.Label
+}
+
+// New returns a new Registry loaded from the files
+func New(log *slog.Logger, paths ...string) (*Registry, error) {
+	r := &Registry{
+		log:    log,
+		paths:  paths,
+		labels: make(map[entities.Address]*entities.Label),
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Label returns the address label
+func (r *Registry) Label(address entities.Address) (*entities.Label, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	l, ok := r.labels[address]
+
+	return l, ok
+}
+
+// Reload loads the files again. Labels are replaced only if all the files
+// are loaded successfully
+func (r *Registry) Reload() error {
+	labels := make(map[entities.Address]*entities.Label)
+
+	for _, path := range r.paths {
+		if err := loadFile(path, labels); err != nil {
+			return fmt.Errorf("error load labels from %s. %w", path, err)
+		}
+	}
+
+	r.mu.Lock()
+	r.labels = labels
+	r.mu.Unlock()
+
+	r.log.Info("labels loaded", slog.Int("labels", len(labels)), slog.Any("files", r.paths))
+
+	return nil
+}
+
+// record is a labeled address record
+type record struct {
+	Address  string `json:"address"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Symbol   string `json:"symbol"`
+}
+
+// loadFile loads labels of a CSV or JSON file into labels
+func loadFile(path string, labels map[entities.Address]*entities.Label) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	var records []record
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		if err := json.NewDecoder(f).Decode(&records); err != nil {
+			return fmt.Errorf("error decode json. %w", err)
+		}
+	case ".csv":
+		if records, err = readCSV(f); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("error unsupported labels file extension %q", ext)
+	}
+
+	for i, rec := range records {
+		if rec.Address == "" {
+			return fmt.Errorf("error record #%d has no address", i)
+		}
+
+		address, err := entities.ParseAddress(rec.Address)
+		if err != nil {
+			return fmt.Errorf("error parse record #%d address. %w", i, err)
+		}
+
+		labels[address] = &entities.Label{
+			Name:     rec.Name,
+			Category: strings.ToLower(rec.Category),
+			Symbol:   rec.Symbol,
+		}
+	}
+
+	return nil
+}
+
+// readCSV reads records of a CSV file with the address,name,category[,symbol] header
+func readCSV(r io.Reader) ([]record, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error read csv header. %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, h := range header {
+		columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+
+	for _, c := range []string{"address", "name", "category"} {
+		if _, ok := columns[c]; !ok {
+			return nil, fmt.Errorf("error csv header has no %s column", c)
+		}
+	}
+
+	var records []record
+
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("error read csv. %w", err)
+		}
+
+		rec := record{
+			Address:  row[columns["address"]],
+			Name:     row[columns["name"]],
+			Category: row[columns["category"]],
+		}
+
+		if i, ok := columns["symbol"]; ok {
+			rec.Symbol = row[i]
+		}
+
+		records = append(records, rec)
+	}
+}
@@ -0,0 +1,108 @@
+package labels
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+const (
+	addressA = "0x000000000000000000000000000000000000aaaa"
+	addressB = "0x000000000000000000000000000000000000BBBB"
+	addressC = "0x000000000000000000000000000000000000cccc"
+	addressD = "0x000000000000000000000000000000000000dddd"
+
+	labelsCSV = `address,name,category
+# Comments are skipped
+0x000000000000000000000000000000000000AAAA,Binance 14,Exchange
+0x000000000000000000000000000000000000bbbb,Arbitrum bridge,bridge
+`
+	labelsJSON = `[
+	{"address": "0x000000000000000000000000000000000000bbbb", "name": "Arbitrum One bridge", "category": "bridge"},
+	{"address": "0x000000000000000000000000000000000000cccc", "name": "Tether USD", "category": "token", "symbol": "USDT"}
+]`
+)
+
+func TestRegistry(t *testing.T) {
+	dir := t.TempDir()
+
+	csvPath := filepath.Join(dir, "labels.csv")
+	jsonPath := filepath.Join(dir, "labels.json")
+
+	writeFile(t, csvPath, labelsCSV)
+	writeFile(t, jsonPath, labelsJSON)
+
+	r, err := New(slog.Default(), csvPath, jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Labels of later files override labels of earlier ones
+	expected := map[string]*entities.Label{
+		addressA: {Name: "Binance 14", Category: entities.LabelCategoryExchange},
+		addressB: {Name: "Arbitrum One bridge", Category: entities.LabelCategoryBridge},
+		addressC: {Name: "Tether USD", Category: entities.LabelCategoryToken, Symbol: "USDT"},
+		addressD: nil,
+	}
+
+	checkLabels(t, r, expected)
+
+	// Broken files keep the loaded labels
+	writeFile(t, jsonPath, "{")
+
+	if err := r.Reload(); err == nil {
+		t.Fatal("expected reload error")
+	}
+
+	checkLabels(t, r, expected)
+
+	// Short addresses are rejected
+	writeFile(t, jsonPath, `[{"address": "0xbbbb", "name": "Arbitrum", "category": "bridge"}]`)
+
+	if err := r.Reload(); !errors.Is(err, entities.ErrorInvalidAddress) {
+		t.Fatalf("invalid reload error: %v | Expected: %s", err, entities.ErrorInvalidAddress)
+	}
+
+	writeFile(t, jsonPath, "[]")
+
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected[addressB] = &entities.Label{Name: "Arbitrum bridge", Category: entities.LabelCategoryBridge}
+	expected[addressC] = nil
+
+	checkLabels(t, r, expected)
+}
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkLabels(t *testing.T, r *Registry, expected map[string]*entities.Label) {
+	t.Helper()
+
+	for address, e := range expected {
+		a, err := entities.ParseAddress(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l, ok := r.Label(a)
+		if ok != (e != nil) {
+			t.Fatalf("invalid label presence of %s: %v", address, ok)
+		}
+
+		if ok && *l != *e {
+			t.Fatalf("invalid label of %s: %+v | Expected: %+v", address, *l, *e)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package prices
+
+import "errors"
+
+var (
+	// ErrorPriceNotFound is thrown when the asset has no price
+	// observed at or before the requested moment
+	ErrorPriceNotFound = errors.New("price not found")
+)
@@ -0,0 +1,201 @@
+// prices package contains historical USD price sources
+// backed by local CSV files and HTTP price services
+package prices
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+// Decimals of native ETH. Its prices may omit decimals
+const etherDecimals = 18
+
+// FileSource is a usecase.PriceSource implementation backed by a CSV file
+// with the asset,timestamp,usd header and a decimals column required for
+// every asset but ETH. Asset is a symbol or a token address, timestamp is
+// a unix time in seconds and usd is a decimal price of a single whole unit.
+// An asset price at a moment is the latest price observed at or before it.
+// FileSource is safe for concurrent use
+type FileSource struct {
+	// map [Asset key => Prices ordered by time]
+	prices map[string][]*entities.Price
+}
+
+// NewFileSource returns a new FileSource loaded from the file
+func NewFileSource(path string) (*FileSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error open prices file. %w", err)
+	}
+
+	defer f.Close()
+
+	prices, err := readCSV(f)
+	if err != nil {
+		return nil, fmt.Errorf("error load prices from %s. %w", path, err)
+	}
+
+	return &FileSource{prices: prices}, nil
+}
+
+// PriceAt returns the asset price at the moment. Tokens are looked up
+// by their address first, then by their symbol
+func (s *FileSource) PriceAt(_ context.Context, asset entities.Asset, at time.Time) (*entities.Price, error) {
+	for _, key := range assetKeys(asset) {
+		prices := s.prices[key]
+
+		// Index of the first price observed after the moment
+		i, _ := slices.BinarySearchFunc(prices, at, func(p *entities.Price, at time.Time) int {
+			if p.Time.After(at) {
+				return 1
+			}
+
+			return -1
+		})
+
+		if i > 0 {
+			return prices[i-1], nil
+		}
+	}
+
+	return nil, notFound(asset, at)
+}
+
+// assetKeys returns the keys the asset prices are stored by in lookup order
+func assetKeys(asset entities.Asset) []string {
+	var keys []string
+
+	if !asset.Token.IsZero() {
+		keys = append(keys, asset.Token.Hex())
+	}
+
+	if asset.Symbol != "" {
+		keys = append(keys, strings.ToUpper(asset.Symbol))
+	}
+
+	return keys
+}
+
+// assetKey returns the key of an asset column value, a lower case
+// address or an upper case symbol
+func assetKey(s string) (string, error) {
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return strings.ToUpper(s), nil
+	}
+
+	address, err := entities.ParseAddress(s)
+	if err != nil {
+		return "", err
+	}
+
+	return address.Hex(), nil
+}
+
+// notFound returns ErrorPriceNotFound of the asset at the moment
+func notFound(asset entities.Asset, at time.Time) error {
+	name := asset.Symbol
+	if name == "" {
+		name = asset.Token.String()
+	}
+
+	return fmt.Errorf("%w for %s at %s", ErrorPriceNotFound, name, at.UTC().Format(time.RFC3339))
+}
+
+// readCSV reads prices of a CSV file with the asset,timestamp,usd[,decimals] header
+func readCSV(r io.Reader) (map[string][]*entities.Price, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error read csv header. %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, h := range header {
+		columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+
+	for _, c := range []string{"asset", "timestamp", "usd"} {
+		if _, ok := columns[c]; !ok {
+			return nil, fmt.Errorf("error csv header has no %s column", c)
+		}
+	}
+
+	prices := make(map[string][]*entities.Price)
+
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("error read csv. %w", err)
+		}
+
+		key, price, err := parseRow(row, columns)
+		if err != nil {
+			return nil, fmt.Errorf("error parse line %d. %w", line, err)
+		}
+
+		prices[key] = append(prices[key], price)
+	}
+
+	for _, p := range prices {
+		slices.SortStableFunc(p, func(a, b *entities.Price) int {
+			return a.Time.Compare(b.Time)
+		})
+	}
+
+	return prices, nil
+}
+
+// parseRow parses a CSV row into the asset key and its price
+func parseRow(row []string, columns map[string]int) (string, *entities.Price, error) {
+	key, err := assetKey(row[columns["asset"]])
+	if err != nil {
+		return "", nil, err
+	}
+
+	ts, err := strconv.ParseInt(row[columns["timestamp"]], 10, 64)
+	if err != nil {
+		return "", nil, fmt.Errorf("error parse timestamp. %w", err)
+	}
+
+	usd, ok := new(big.Rat).SetString(row[columns["usd"]])
+	if !ok {
+		return "", nil, fmt.Errorf("error invalid usd price %q", row[columns["usd"]])
+	}
+
+	price := &entities.Price{
+		USD:      usd,
+		Decimals: etherDecimals,
+		Time:     time.Unix(ts, 0),
+	}
+
+	i, ok := columns["decimals"]
+
+	switch {
+	case ok && row[i] != "":
+		if price.Decimals, err = strconv.Atoi(row[i]); err != nil {
+			return "", nil, fmt.Errorf("error parse decimals. %w", err)
+		}
+	case key != entities.SymbolETH:
+		return "", nil, fmt.Errorf("error no decimals of %s", row[columns["asset"]])
+	}
+
+	return key, price, nil
+}
@@ -0,0 +1,159 @@
+package prices
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+	"github.com/optclblast/blk/internal/usecase"
+)
+
+const pricesCSV = `asset,timestamp,usd,decimals
+# Rows may come in any order
+ETH,1700000600,2050.5,
+eth,1700000000,2000,
+USDT,1700000000,0.999,6
+0x000000000000000000000000000000000000AAAA,1700000000,1.01,6
+`
+
+var addressA = entities.Address{18: 0xaa, 19: 0xaa}
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.csv")
+
+	if err := os.WriteFile(path, []byte(pricesCSV), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewFileSource(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range priceTests {
+		t.Run(tc.Title, func(t *testing.T) {
+			checkPrice(t, s, tc)
+		})
+	}
+}
+
+type PriceTestCase struct {
+	Title            string
+	Asset            entities.Asset
+	At               int64
+	ExpectedUSD      string
+	ExpectedDecimals int
+	ExpectedTime     int64
+	NotFound         bool
+}
+
+var priceTests = []PriceTestCase{
+	{
+		Title:            "Exact moment",
+		Asset:            entities.Asset{Symbol: entities.SymbolETH},
+		At:               1700000000,
+		ExpectedUSD:      "2000",
+		ExpectedDecimals: 18,
+		ExpectedTime:     1700000000,
+	},
+	{
+		Title:            "Latest price before the moment",
+		Asset:            entities.Asset{Symbol: entities.SymbolETH},
+		At:               1700000599,
+		ExpectedUSD:      "2000",
+		ExpectedDecimals: 18,
+		ExpectedTime:     1700000000,
+	},
+	{
+		Title:            "Latest price",
+		Asset:            entities.Asset{Symbol: entities.SymbolETH},
+		At:               1800000000,
+		ExpectedUSD:      "4101/2",
+		ExpectedDecimals: 18,
+		ExpectedTime:     1700000600,
+	},
+	{
+		Title:    "Before the first price",
+		Asset:    entities.Asset{Symbol: entities.SymbolETH},
+		At:       1699999999,
+		NotFound: true,
+	},
+	{
+		Title:            "Token by symbol",
+		Asset:            entities.Asset{Token: entities.Address{19: 0x01}, Symbol: "usdt"},
+		At:               1700000001,
+		ExpectedUSD:      "999/1000",
+		ExpectedDecimals: 6,
+		ExpectedTime:     1700000000,
+	},
+	{
+		Title:            "Token address takes precedence over symbol",
+		Asset:            entities.Asset{Token: addressA, Symbol: "USDT"},
+		At:               1700000001,
+		ExpectedUSD:      "101/100",
+		ExpectedDecimals: 6,
+		ExpectedTime:     1700000000,
+	},
+	{
+		Title:    "Unknown asset",
+		Asset:    entities.Asset{Token: entities.Address{19: 0x01}},
+		At:       1700000001,
+		NotFound: true,
+	},
+}
+
+// checkPrice checks the source price of the test case
+func checkPrice(t *testing.T, s usecase.PriceSource, tc PriceTestCase) {
+	t.Helper()
+
+	price, err := s.PriceAt(context.TODO(), tc.Asset, time.Unix(tc.At, 0))
+
+	if tc.NotFound {
+		if !errors.Is(err, ErrorPriceNotFound) {
+			t.Fatalf("invalid error: %v | Expected: %s", err, ErrorPriceNotFound)
+		}
+
+		return
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if price.USD.RatString() != tc.ExpectedUSD ||
+		price.Decimals != tc.ExpectedDecimals ||
+		price.Time.Unix() != tc.ExpectedTime {
+		t.Fatalf(
+			"invalid price: %s, %d decimals at %d | Expected: %s, %d decimals at %d",
+			price.USD.RatString(), price.Decimals, price.Time.Unix(),
+			tc.ExpectedUSD, tc.ExpectedDecimals, tc.ExpectedTime,
+		)
+	}
+}
+
+func TestFileSourceErrors(t *testing.T) {
+	files := map[string]string{
+		"no usd column":    "asset,timestamp\nETH,1700000000\n",
+		"invalid price":    "asset,timestamp,usd\nETH,1700000000,abc\n",
+		"invalid address":  "asset,timestamp,usd\n0xaaaa,1700000000,1\n",
+		"invalid decimals": "asset,timestamp,usd,decimals\nETH,1700000000,1,x\n",
+		"no decimals":      "asset,timestamp,usd\nUSDT,1700000000,1\n",
+		"empty decimals":   "asset,timestamp,usd,decimals\nDAI,1700000000,1,\n",
+	}
+
+	for title, data := range files {
+		path := filepath.Join(t.TempDir(), "prices.csv")
+
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := NewFileSource(path); err == nil {
+			t.Fatalf("%s: expected load error", title)
+		}
+	}
+}
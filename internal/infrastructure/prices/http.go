@@ -0,0 +1,114 @@
+package prices
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+// Default price service request timeout
+const defaultTimeout = 10 * time.Second
+
+// HTTPSource is a usecase.PriceSource implementation querying a price service.
+// A price is requested with GET {baseURL}/price?symbol=&address=&timestamp=
+// where address is empty for native ETH and timestamp is a unix time in
+// seconds. The service responds with a {"usd", "decimals", "timestamp"}
+// object or 404 Not Found if the asset has no price at the moment.
+// Decimals may be omitted for native ETH only
+type HTTPSource struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPSource returns a new HTTPSource of the price service
+func NewHTTPSource(baseURL string) *HTTPSource {
+	return &HTTPSource{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: defaultTimeout},
+	}
+}
+
+// priceResponse is a price service response
+type priceResponse struct {
+	// Decimal price of a single whole unit
+	USD      json.Number `json:"usd"`
+	Decimals *int        `json:"decimals"`
+	// Unix time the price was observed at. Zero if unknown
+	Timestamp int64 `json:"timestamp"`
+}
+
+// PriceAt returns the asset price at the moment
+func (s *HTTPSource) PriceAt(
+	ctx context.Context,
+	asset entities.Asset,
+	at time.Time,
+) (*entities.Price, error) {
+	query := url.Values{
+		"symbol":    {asset.Symbol},
+		"timestamp": {strconv.FormatInt(at.Unix(), 10)},
+	}
+
+	if !asset.Token.IsZero() {
+		query.Set("address", asset.Token.String())
+	}
+
+	endpoint := s.baseURL + "/price?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error build request. %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error request price. %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, notFound(asset, at)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error price service responded with %s", resp.Status)
+	}
+
+	var out priceResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("error decode price. %w", err)
+	}
+
+	usd, ok := new(big.Rat).SetString(out.USD.String())
+	if !ok {
+		return nil, fmt.Errorf("error invalid usd price %q", out.USD)
+	}
+
+	price := &entities.Price{
+		USD:      usd,
+		Decimals: etherDecimals,
+		Time:     at,
+	}
+
+	switch {
+	case out.Decimals != nil:
+		price.Decimals = *out.Decimals
+	case !asset.Token.IsZero():
+		return nil, fmt.Errorf("error price service responded with no decimals of %s", asset.Token)
+	}
+
+	if out.Timestamp != 0 {
+		price.Time = time.Unix(out.Timestamp, 0)
+	}
+
+	return price, nil
+}
@@ -0,0 +1,103 @@
+package prices
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+func TestHTTPSource(t *testing.T) {
+	// A local price service stub backed by the same prices as the file source
+	path := filepath.Join(t.TempDir(), "prices.csv")
+
+	if err := os.WriteFile(path, []byte(pricesCSV), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := NewFileSource(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/price" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var (
+			query = r.URL.Query()
+			asset = entities.Asset{Symbol: query.Get("symbol")}
+		)
+
+		if address := query.Get("address"); address != "" {
+			token, err := entities.ParseAddress(address)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			asset.Token = token
+		}
+
+		ts, err := strconv.ParseInt(query.Get("timestamp"), 10, 64)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		price, err := file.PriceAt(r.Context(), asset, time.Unix(ts, 0))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"usd":       json.Number(price.USD.FloatString(3)),
+			"decimals":  price.Decimals,
+			"timestamp": price.Time.Unix(),
+		})
+	}))
+	defer srv.Close()
+
+	s := NewHTTPSource(srv.URL + "/")
+
+	for _, tc := range priceTests {
+		t.Run(tc.Title, func(t *testing.T) {
+			checkPrice(t, s, tc)
+		})
+	}
+}
+
+func TestHTTPSourceNoDecimals(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"usd": "2000", "timestamp": 1700000000}`))
+	}))
+	defer srv.Close()
+
+	s := NewHTTPSource(srv.URL)
+
+	// Native ETH decimals are known
+	checkPrice(t, s, PriceTestCase{
+		Asset:            entities.Asset{Symbol: entities.SymbolETH},
+		At:               1700000000,
+		ExpectedUSD:      "2000",
+		ExpectedDecimals: 18,
+		ExpectedTime:     1700000000,
+	})
+
+	// Token decimals are required
+	asset := entities.Asset{Token: addressA, Symbol: "USDT"}
+
+	if _, err := s.PriceAt(context.TODO(), asset, time.Unix(1700000000, 0)); err == nil {
+		t.Fatal("expected no decimals error")
+	}
+}
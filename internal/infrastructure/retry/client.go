@@ -0,0 +1,218 @@
+// retry package contains a node client decorator that retries
+// transient node provider errors with an exponential backoff
+package retry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"math/rand"
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+	"github.com/optclblast/blk/internal/infrastructure/ethrpc"
+	"github.com/optclblast/blk/internal/logger"
+	"github.com/optclblast/blk/internal/usecase"
+)
+
+const (
+	defaultMaxAttempts = 5
+	defaultBaseDelay   = 200 * time.Millisecond
+	defaultMaxDelay    = 5 * time.Second
+)
+
+// Client is a usecase.NodeClient decorator. Calls that failed with a
+// transient error are retried with a jittered exponential backoff.
+// A delay requested by the provider with Retry-After is honored.
+type Client struct {
+	log         *slog.Logger
+	client      usecase.NodeClient
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// New returns a new retrying Client wrapped around client
+func New(
+	log *slog.Logger,
+	client usecase.NodeClient,
+	opts ...Option,
+) *Client {
+	c := &Client{
+		log:         log,
+		client:      client,
+		maxAttempts: defaultMaxAttempts,
+		baseDelay:   defaultBaseDelay,
+		maxDelay:    defaultMaxDelay,
+	}
+
+	// Apply options
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Option configures Client
+type Option func(c *Client)
+
+// MaxAttempts sets a specific retry budget: the maximum amount
+// of attempts per call, including the first one
+func MaxAttempts(attempts int) Option {
+	return func(c *Client) {
+		if attempts > 0 {
+			c.maxAttempts = attempts
+		}
+	}
+}
+
+// BaseDelay sets a specific delay before the first retry
+func BaseDelay(delay time.Duration) Option {
+	return func(c *Client) {
+		c.baseDelay = delay
+	}
+}
+
+// MaxDelay sets a specific upper bound of a backoff delay
+func MaxDelay(delay time.Duration) Option {
+	return func(c *Client) {
+		c.maxDelay = delay
+	}
+}
+
+// backoff returns a delay before the retry after attempt failed attempts.
+// The delay is picked randomly from [0, min(maxDelay, baseDelay * 2^(attempt-1))]
+// unless the provider requested a longer delay
+func (c *Client) backoff(attempt int, err error) time.Duration {
+	ceil := c.baseDelay << (attempt - 1)
+	if ceil > c.maxDelay || ceil <= 0 {
+		ceil = c.maxDelay
+	}
+
+	delay := time.Duration(rand.Int63n(int64(ceil) + 1))
+
+	if retryAfter := ethrpc.RetryAfter(err); retryAfter > delay {
+		delay = retryAfter
+	}
+
+	return delay
+}
+
+// call calls fn until it succeeds, fails with a permanent
+// error or the retry budget runs out
+func call[T any](
+	ctx context.Context,
+	c *Client,
+	method string,
+	fn func() (T, error),
+) (T, error) {
+	var out T
+
+	for attempt := 1; ; attempt++ {
+		res, err := fn()
+		if err == nil {
+			return res, nil
+		}
+
+		if ctx.Err() != nil || !ethrpc.IsTemporary(err) {
+			return out, err
+		}
+
+		if attempt >= c.maxAttempts {
+			return out, fmt.Errorf("error retry budget of %d attempts exceeded. %w", c.maxAttempts, err)
+		}
+
+		delay := c.backoff(attempt, err)
+
+		c.log.Debug(
+			"retrying node call",
+			slog.String("method", method),
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			logger.Err(err),
+		)
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return out, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// LastBlockNumber returns a last block number
+func (c *Client) LastBlockNumber(ctx context.Context) (entities.BlockNumber, error) {
+	return call(ctx, c, "LastBlockNumber", func() (entities.BlockNumber, error) {
+		return c.client.LastBlockNumber(ctx)
+	})
+}
+
+// BlockInfoByNumber returns an info about block by its number
+func (c *Client) BlockInfoByNumber(ctx context.Context, num entities.BlockNumber) (*entities.Block, error) {
+	return call(ctx, c, "BlockInfoByNumber", func() (*entities.Block, error) {
+		return c.client.BlockInfoByNumber(ctx, num)
+	})
+}
+
+// BlockHeaderByNumber returns an info about block by its number
+// or tag without transactions details
+func (c *Client) BlockHeaderByNumber(ctx context.Context, num entities.BlockNumber) (*entities.Block, error) {
+	return call(ctx, c, "BlockHeaderByNumber", func() (*entities.Block, error) {
+		return c.client.BlockHeaderByNumber(ctx, num)
+	})
+}
+
+// BlocksByNumbers returns blocks info by their numbers using a batch request
+func (c *Client) BlocksByNumbers(ctx context.Context, nums []entities.BlockNumber) ([]*entities.Block, error) {
+	return call(ctx, c, "BlocksByNumbers", func() ([]*entities.Block, error) {
+		return c.client.BlocksByNumbers(ctx, nums)
+	})
+}
+
+// BlockReceipts returns receipts of all the transactions included into the block
+func (c *Client) BlockReceipts(ctx context.Context, num entities.BlockNumber) ([]*entities.Receipt, error) {
+	return call(ctx, c, "BlockReceipts", func() ([]*entities.Receipt, error) {
+		return c.client.BlockReceipts(ctx, num)
+	})
+}
+
+// BlockTraces returns call traces of all the transactions included into the block
+func (c *Client) BlockTraces(ctx context.Context, num entities.BlockNumber) ([]*entities.TxTrace, error) {
+	return call(ctx, c, "BlockTraces", func() ([]*entities.TxTrace, error) {
+		return c.client.BlockTraces(ctx, num)
+	})
+}
+
+// BalanceAt returns the address balance in wei at the block
+func (c *Client) BalanceAt(ctx context.Context, address entities.Address, num entities.BlockNumber) (*big.Int, error) {
+	return call(ctx, c, "BalanceAt", func() (*big.Int, error) {
+		return c.client.BalanceAt(ctx, address, num)
+	})
+}
+
+// CodeAt returns the hex encoded code deployed at the address
+func (c *Client) CodeAt(ctx context.Context, address entities.Address, num entities.BlockNumber) (string, error) {
+	return call(ctx, c, "CodeAt", func() (string, error) {
+		return c.client.CodeAt(ctx, address, num)
+	})
+}
+
+// Logs returns event logs matching the filter
+func (c *Client) Logs(ctx context.Context, filter entities.LogFilter) ([]*entities.Log, error) {
+	return call(ctx, c, "Logs", func() ([]*entities.Log, error) {
+		return c.client.Logs(ctx, filter)
+	})
+}
+
+// TransactionReceipt returns a transaction receipt by the transaction hash
+func (c *Client) TransactionReceipt(ctx context.Context, hash entities.Hash) (*entities.Receipt, error) {
+	return call(ctx, c, "TransactionReceipt", func() (*entities.Receipt, error) {
+		return c.client.TransactionReceipt(ctx, hash)
+	})
+}
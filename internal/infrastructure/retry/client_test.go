@@ -0,0 +1,105 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+	"github.com/optclblast/blk/internal/infrastructure/ethrpc"
+	"github.com/optclblast/blk/internal/usecase"
+)
+
+// nodeClientMock is a usecase.NodeClient mock that fails
+// LastBlockNumber calls with predefined errors
+type nodeClientMock struct {
+	usecase.NodeClient
+	errs  []error
+	calls int
+}
+
+func (m *nodeClientMock) LastBlockNumber(context.Context) (entities.BlockNumber, error) {
+	m.calls++
+
+	if m.calls <= len(m.errs) {
+		return "", m.errs[m.calls-1]
+	}
+
+	return "0x1", nil
+}
+
+func TestRetry(t *testing.T) {
+	for _, tc := range tests {
+		t.Run(tc.Title, func(t *testing.T) {
+			mock := &nodeClientMock{errs: tc.Errors}
+
+			c := New(
+				slog.Default(),
+				mock,
+				MaxAttempts(3),
+				BaseDelay(time.Millisecond),
+				MaxDelay(time.Millisecond),
+			)
+
+			start := time.Now()
+
+			_, err := c.LastBlockNumber(context.TODO())
+
+			if tc.MustFail && err == nil {
+				t.Fatal("call must fail")
+			}
+
+			if !tc.MustFail && err != nil {
+				t.Fatalf("call must pass but it is failed. %s\n", err.Error())
+			}
+
+			if mock.calls != tc.ExpectedCalls {
+				t.Fatalf("invalid calls: %d | Expected: %d", mock.calls, tc.ExpectedCalls)
+			}
+
+			if elapsed := time.Since(start); elapsed < tc.MinElapsed {
+				t.Fatalf("Retry-After is not honored: %s | Expected at least: %s", elapsed, tc.MinElapsed)
+			}
+		})
+	}
+}
+
+type TestCase struct {
+	Title         string
+	Errors        []error
+	ExpectedCalls int
+	MinElapsed    time.Duration
+	MustFail      bool
+}
+
+var tests = []TestCase{
+	{
+		Title:         "Succeeded after rate limit",
+		Errors:        []error{ethrpc.ErrorRateLimitExceeded, ethrpc.ErrorProviderUnavailable},
+		ExpectedCalls: 3,
+	},
+	{
+		Title:         "Retry-After is honored",
+		Errors:        []error{&ethrpc.RateLimitError{RetryAfter: 50 * time.Millisecond}},
+		ExpectedCalls: 2,
+		MinElapsed:    50 * time.Millisecond,
+	},
+	{
+		Title: "Retry budget exceeded",
+		Errors: []error{
+			ethrpc.ErrorRateLimitExceeded,
+			ethrpc.ErrorRateLimitExceeded,
+			ethrpc.ErrorRateLimitExceeded,
+		},
+		ExpectedCalls: 3,
+		MustFail:      true,
+	},
+	{
+		Title:         "Permanent error is not retried",
+		Errors:        []error{errors.New("invalid params")},
+		ExpectedCalls: 1,
+		MustFail:      true,
+	},
+}
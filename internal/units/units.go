@@ -0,0 +1,79 @@
+// units package formats wei amounts in ether denominations
+// using exact fixed-point math
+package units
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Unit is an ether denomination
+type Unit string
+
+const (
+	UnitWei   Unit = "wei"
+	UnitGwei  Unit = "gwei"
+	UnitEther Unit = "ether"
+)
+
+// ParseUnit parses a unit name of any letter case. An empty string means UnitWei
+func ParseUnit(s string) (Unit, error) {
+	switch u := Unit(strings.ToLower(s)); u {
+	case "":
+		return UnitWei, nil
+	case UnitWei, UnitGwei, UnitEther:
+		return u, nil
+	default:
+		return "", fmt.Errorf("error unknown unit %q", s)
+	}
+}
+
+// Decimals returns the amount of wei decimal digits in a single unit
+func (u Unit) Decimals() int {
+	switch u {
+	case UnitWei:
+		return 0
+	case UnitGwei:
+		return 9
+	case UnitEther:
+		return 18
+	default:
+		return 0
+	}
+}
+
+// Format returns the wei amount as an exact decimal string in the unit,
+// e.g. 1250000000000000000 wei is "1.25" ether. Trailing fractional zeros
+// are trimmed. Nil amount is "0"
+func Format(wei *big.Int, u Unit) string {
+	if wei == nil {
+		return "0"
+	}
+
+	decimals := u.Decimals()
+	if decimals == 0 {
+		return wei.String()
+	}
+
+	base := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	whole, frac := new(big.Int).QuoRem(new(big.Int).Abs(wei), base, new(big.Int))
+
+	var sb strings.Builder
+
+	if wei.Sign() < 0 {
+		sb.WriteByte('-')
+	}
+
+	sb.WriteString(whole.String())
+
+	if frac.Sign() != 0 {
+		digits := frac.String()
+
+		sb.WriteByte('.')
+		sb.WriteString(strings.Repeat("0", decimals-len(digits)))
+		sb.WriteString(strings.TrimRight(digits, "0"))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,94 @@
+package units
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	for _, tc := range formatTests {
+		t.Run(tc.Title, func(t *testing.T) {
+			wei, ok := new(big.Int).SetString(tc.Wei, 10)
+			if !ok {
+				t.Fatalf("invalid test amount %s", tc.Wei)
+			}
+
+			if s := Format(wei, tc.Unit); s != tc.Expected {
+				t.Fatalf("invalid result: %s | Expected: %s", s, tc.Expected)
+			}
+		})
+	}
+}
+
+type FormatTestCase struct {
+	Title    string
+	Wei      string
+	Unit     Unit
+	Expected string
+}
+
+var formatTests = []FormatTestCase{
+	{
+		Title:    "Wei",
+		Wei:      "-1250000000000000000",
+		Unit:     UnitWei,
+		Expected: "-1250000000000000000",
+	},
+	{
+		Title:    "Whole ether",
+		Wei:      "2000000000000000000",
+		Unit:     UnitEther,
+		Expected: "2",
+	},
+	{
+		Title:    "Fractional ether",
+		Wei:      "-1250000000000000000",
+		Unit:     UnitEther,
+		Expected: "-1.25",
+	},
+	{
+		Title:    "Single wei in ether",
+		Wei:      "1",
+		Unit:     UnitEther,
+		Expected: "0.000000000000000001",
+	},
+	{
+		Title:    "Negative wei fraction in ether",
+		Wei:      "-420000000000000",
+		Unit:     UnitEther,
+		Expected: "-0.00042",
+	},
+	{
+		Title:    "Gwei",
+		Wei:      "21000000000123",
+		Unit:     UnitGwei,
+		Expected: "21000.000000123",
+	},
+	{
+		Title:    "Zero",
+		Wei:      "0",
+		Unit:     UnitEther,
+		Expected: "0",
+	},
+	{
+		Title:    "Beyond float64 precision",
+		Wei:      "123456789012345678901234567890",
+		Unit:     UnitEther,
+		Expected: "123456789012.34567890123456789",
+	},
+}
+
+func TestParseUnit(t *testing.T) {
+	expected := map[string]Unit{"": UnitWei, "wei": UnitWei, "GWEI": UnitGwei, "Ether": UnitEther}
+
+	for s, e := range expected {
+		u, err := ParseUnit(s)
+		if err != nil || u != e {
+			t.Fatalf("invalid unit of %q: %s, %v | Expected: %s", s, u, err, e)
+		}
+	}
+
+	if _, err := ParseUnit("finney"); err == nil {
+		t.Fatal("unknown unit must fail")
+	}
+}
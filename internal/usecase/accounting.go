@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+// Accounting defines which balance changes are taken into account in
+// addition to transactions value. Accounting is a set of flags.
+type Accounting uint8
+
+const (
+	// Gas fees paid by transactions senders. Priority fee is credited to
+	// the block fee recipient, base fee and blob fee are burned.
+	// Requires transaction receipts.
+	AccountingFees Accounting = 1 << iota
+	// Value transferred by internal contract calls and self destructs.
+	// Requires debug_traceBlockByNumber with the callTracer.
+	AccountingTraces
+	// Priority fees credited to the block fee recipient. Senders are not
+	// debited unless AccountingFees is set. Requires transaction receipts.
+	AccountingRewards
+	// Beacon chain withdrawals credited to their addresses
+	AccountingWithdrawals
+)
+
+// Has reports whether the flag is set
+func (a Accounting) Has(flag Accounting) bool {
+	return a&flag != 0
+}
+
+// ParseAccounting parses a comma separated list of accounting flags,
+// e.g. "fees,traces". An empty string means value only accounting.
+func ParseAccounting(s string) (Accounting, error) {
+	var a Accounting
+
+	for _, f := range strings.Split(s, ",") {
+		switch strings.TrimSpace(f) {
+		case "", "value":
+		case "fees":
+			a |= AccountingFees
+		case "traces":
+			a |= AccountingTraces
+		case "rewards":
+			a |= AccountingRewards
+		case "withdrawals":
+			a |= AccountingWithdrawals
+		default:
+			return 0, fmt.Errorf("error unknown accounting flag %q", f)
+		}
+	}
+
+	return a, nil
+}
+
+// transactionTransfers returns all the transfers caused by the transaction
+// according to the accounting mode. Value of a reverted transaction stays
+// with the sender, so only its fee is transferred
+func transactionTransfers(
+	block *entities.Block,
+	tx *entities.Transaction,
+	accounting Accounting,
+) []*entities.Transfer {
+	var transfers []*entities.Transfer
+
+	if !tx.Failed() {
+		transfers = append(transfers, valueTransfer(tx))
+	}
+
+	if accounting.Has(AccountingTraces) && tx.Trace != nil {
+		for _, tr := range tx.Trace.InternalTransfers(tx.Hash) {
+			tr.TxType = tx.Type
+			transfers = append(transfers, tr)
+		}
+	}
+
+	// Receipts of contract creations are attached in any mode
+	if tx.Receipt == nil || (!accounting.Has(AccountingFees) && !accounting.Has(AccountingRewards)) {
+		return transfers
+	}
+
+	fee := tx.Receipt.Fee()
+
+	burned := tx.Receipt.BlobFee()
+	if block.BaseFeePerGas != nil {
+		burned.Add(burned, new(big.Int).Mul(tx.Receipt.GasUsed, block.BaseFeePerGas))
+	}
+
+	tip := new(big.Int).Sub(fee, burned)
+
+	if !accounting.Has(AccountingFees) {
+		// Fee recipient revenue only
+		if accounting.Has(AccountingRewards) && tip.Sign() > 0 {
+			transfers = append(transfers, &entities.Transfer{
+				To:     block.Miner,
+				Value:  tip,
+				Kind:   entities.TransferKindReward,
+				TxHash: tx.Hash,
+				TxType: tx.Type,
+			})
+		}
+
+		return transfers
+	}
+
+	if tip.Sign() > 0 {
+		transfers = append(transfers, &entities.Transfer{
+			From:   tx.From,
+			To:     block.Miner,
+			Value:  tip,
+			Kind:   entities.TransferKindTip,
+			TxHash: tx.Hash,
+			TxType: tx.Type,
+		})
+	}
+
+	if burned.Sign() > 0 {
+		transfers = append(transfers, &entities.Transfer{
+			From:   tx.From,
+			Value:  burned,
+			Kind:   entities.TransferKindBurn,
+			TxHash: tx.Hash,
+			TxType: tx.Type,
+		})
+	}
+
+	return transfers
+}
+
+// valueTransfer returns the transfer of the transaction value
+func valueTransfer(tx *entities.Transaction) *entities.Transfer {
+	t := &entities.Transfer{
+		From:   tx.From,
+		Value:  tx.Value,
+		Kind:   entities.TransferKindValue,
+		TxHash: tx.Hash,
+		TxType: tx.Type,
+	}
+
+	if tx.To != nil {
+		t.To = *tx.To
+	}
+
+	// Value of a contract creation is credited to the created contract
+	if tx.IsCreation() {
+		t.Creation = true
+
+		if tx.Receipt != nil && tx.Receipt.ContractAddress != nil {
+			t.To = *tx.Receipt.ContractAddress
+		}
+	}
+
+	return t
+}
+
+// withdrawalTransfers returns transfers of the block beacon chain withdrawals
+// according to the accounting mode
+func withdrawalTransfers(block *entities.Block, accounting Accounting) []*entities.Transfer {
+	if !accounting.Has(AccountingWithdrawals) {
+		return nil
+	}
+
+	transfers := make([]*entities.Transfer, 0, len(block.Withdrawals))
+
+	for _, w := range block.Withdrawals {
+		transfers = append(transfers, &entities.Transfer{
+			To:    w.Address,
+			Value: w.AmountWei(),
+			Kind:  entities.TransferKindWithdrawal,
+		})
+	}
+
+	return transfers
+}
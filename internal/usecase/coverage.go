@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"sync"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+// coverageTracker tracks blocks processed by a transfers stream
+type coverageTracker struct {
+	mu        sync.Mutex
+	from, to  uint64
+	processed map[uint64]struct{}
+}
+
+// newCoverageTracker returns a tracker of the [from, to] blocks window
+func newCoverageTracker(from, to uint64) *coverageTracker {
+	return &coverageTracker{
+		from:      from,
+		to:        to,
+		processed: make(map[uint64]struct{}, to-from+1),
+	}
+}
+
+// add marks the block as processed
+func (c *coverageTracker) add(num uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.processed[num] = struct{}{}
+}
+
+// coverage returns the window coverage
+func (c *coverageTracker) coverage() *entities.Coverage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := &entities.Coverage{
+		FromBlock: c.from,
+		ToBlock:   c.to,
+		Requested: int(c.to - c.from + 1),
+		Processed: make([]uint64, 0, len(c.processed)),
+		Missing:   []uint64{},
+	}
+
+	for num := c.from; num <= c.to; num++ {
+		if _, ok := c.processed[num]; ok {
+			out.Processed = append(out.Processed, num)
+		} else {
+			out.Missing = append(out.Missing, num)
+		}
+	}
+
+	return out
+}
@@ -2,9 +2,12 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"log/slog"
 	"math/big"
 	"math/rand"
+	"regexp"
 	"slices"
 	"testing"
 
@@ -16,26 +19,465 @@ func TestAddressWithBiggestDelta(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.Title, func(t *testing.T) {
-			txCh := make(chan *entities.Transaction, len(tc.Block.Transactions))
+			report, err := ethInteractor.walletsDeltas(
+				context.TODO(),
+				blockTransfers(tc.Block, 0),
+			)
+			if err != nil {
+				t.Fatalf("error: %s\n", err.Error())
+			}
 
-			for _, txs := range tc.Block.Transactions {
-				txCh <- txs
+			var walletAddr entities.Address
+			if top := report.Wallets.Top(1); len(top) > 0 {
+				walletAddr = top[0].Address
 			}
 
-			close(txCh)
+			if walletAddr != tc.ExpectedResult {
+				t.Fatalf("invalid result: %s | Expected: %s", walletAddr, tc.ExpectedResult)
+			}
+		})
+	}
+}
+
+func TestTopChangedWallets(t *testing.T) {
+	ethInteractor := &ethInteractor{log: slog.Default()}
+
+	report, err := ethInteractor.walletsDeltas(
+		context.TODO(),
+		blockTransfers(tests[0].Block, 0),
+	)
+	if err != nil {
+		t.Fatalf("error: %s\n", err.Error())
+	}
+
+	top := report.Wallets.Top(2)
+	if len(top) != 2 {
+		t.Fatalf("invalid result length: %d | Expected: 2", len(top))
+	}
+
+	// B: +1025 in 3 txs, F: -1000 in 1 tx
+	expected := []struct {
+		Address entities.Address
+		Delta   int64
+		Inflow  int64
+		Outflow int64
+		TxCount int
+	}{
+		{Address: addr("B"), Delta: 1025, Inflow: 1025, Outflow: 0, TxCount: 3},
+		{Address: addr("F"), Delta: -1000, Inflow: 0, Outflow: 1000, TxCount: 1},
+	}
+
+	for i, e := range expected {
+		w := top[i]
 
-			walletAddr, err := ethInteractor.addressWithBiggestDelta(context.TODO(), txCh)
+		if w.Address != e.Address ||
+			w.Delta.Int64() != e.Delta ||
+			w.Inflow.Int64() != e.Inflow ||
+			w.Outflow.Int64() != e.Outflow ||
+			w.TxCount != e.TxCount {
+			t.Fatalf(
+				"invalid wallet #%d: %s %s %s %s %d | Expected: %v",
+				i, w.Address, w.Delta, w.Inflow, w.Outflow, w.TxCount, e,
+			)
+		}
+	}
+}
+
+func TestRankings(t *testing.T) {
+	ethInteractor := &ethInteractor{log: slog.Default()}
+
+	for _, tc := range rankingTests {
+		t.Run(string(tc.Ranking), func(t *testing.T) {
+			report, err := ethInteractor.walletsDeltas(
+				context.TODO(),
+				blockTransfers(tests[0].Block, 0),
+			)
 			if err != nil {
 				t.Fatalf("error: %s\n", err.Error())
 			}
 
-			if !slices.Contains(tc.ExpectedResult, walletAddr) {
-				t.Fatalf("invalid result: %s | Expected: %v", walletAddr, tc.ExpectedResult)
+			top := report.Wallets.TopBy(tc.Ranking, 0)
+
+			addresses := make([]entities.Address, len(top))
+			for i, w := range top {
+				addresses[i] = w.Address
+			}
+
+			if !slices.Equal(addresses, tc.ExpectedResult) {
+				t.Fatalf("invalid result: %v | Expected: %v", addresses, tc.ExpectedResult)
 			}
 		})
 	}
 }
 
+type RankingTestCase struct {
+	Ranking        entities.Ranking
+	ExpectedResult []entities.Address
+}
+
+// B: +1025 (1025 in), F: -1000 (1000 out), A: -25 (1000 in, 1025 out)
+var rankingTests = []RankingTestCase{
+	{Ranking: entities.RankingAbs, ExpectedResult: []entities.Address{addr("B"), addr("F"), addr("A")}},
+	{Ranking: entities.RankingGain, ExpectedResult: []entities.Address{addr("B")}},
+	{Ranking: entities.RankingLoss, ExpectedResult: []entities.Address{addr("F"), addr("A")}},
+	{Ranking: entities.RankingVolume, ExpectedResult: []entities.Address{addr("A"), addr("B"), addr("F")}},
+}
+
+func TestFeesAccounting(t *testing.T) {
+	ethInteractor := &ethInteractor{log: slog.Default()}
+
+	block := &entities.Block{
+		BaseFeePerGas: big.NewInt(10),
+		Miner:         addr("M"),
+		Transactions: []*entities.Transaction{
+			{
+				Hash:  hash("1"),
+				From:  addr("A"),
+				To:    to("B"),
+				Value: big.NewInt(100),
+				Receipt: &entities.Receipt{
+					GasUsed:           big.NewInt(2),
+					EffectiveGasPrice: big.NewInt(15),
+					BlobGasUsed:       new(big.Int),
+					BlobGasPrice:      new(big.Int),
+					Status:            big.NewInt(1),
+				},
+			},
+			{
+				// Reverted transaction pays the fee only
+				Hash:  hash("2"),
+				From:  addr("A"),
+				To:    to("C"),
+				Value: big.NewInt(50),
+				Receipt: &entities.Receipt{
+					GasUsed:           big.NewInt(1),
+					EffectiveGasPrice: big.NewInt(15),
+					Status:            new(big.Int),
+				},
+			},
+		},
+	}
+
+	report, err := ethInteractor.walletsDeltas(
+		context.TODO(),
+		blockTransfers(block, AccountingFees),
+	)
+	if err != nil {
+		t.Fatalf("error: %s\n", err.Error())
+	}
+
+	// Fees are 2 * 15 = 30 and 1 * 15 = 15. 2 * 10 + 1 * 10 = 30 is burned
+	// and 10 + 5 = 15 are tips. C receives nothing
+	expected := map[entities.Address]int64{
+		addr("A"): -145,
+		addr("B"): 100,
+		addr("M"): 15,
+	}
+
+	if len(report.Wallets) != len(expected) {
+		t.Fatalf("invalid wallets count: %d | Expected: %d", len(report.Wallets), len(expected))
+	}
+
+	for _, w := range report.Wallets {
+		if w.Delta.Int64() != expected[w.Address] {
+			t.Fatalf("invalid delta of %s: %s | Expected: %d", w.Address, w.Delta, expected[w.Address])
+		}
+	}
+
+	if report.Burned.Int64() != 30 {
+		t.Fatalf("invalid burned amount: %s | Expected: 30", report.Burned)
+	}
+}
+
+func TestTxTypesBreakdown(t *testing.T) {
+	ethInteractor := &ethInteractor{log: slog.Default()}
+
+	block := &entities.Block{
+		BaseFeePerGas: big.NewInt(10),
+		Miner:         addr("M"),
+		Transactions: []*entities.Transaction{
+			{
+				Hash:  hash("1"),
+				From:  addr("A"),
+				To:    to("B"),
+				Value: big.NewInt(100),
+				Type:  entities.TxTypeDynamicFee,
+				Receipt: &entities.Receipt{
+					GasUsed:           big.NewInt(2),
+					EffectiveGasPrice: big.NewInt(15),
+				},
+			},
+			{
+				Hash:  hash("2"),
+				From:  addr("A"),
+				To:    to("C"),
+				Value: big.NewInt(5),
+				Type:  entities.TxTypeDynamicFee,
+				Receipt: &entities.Receipt{
+					GasUsed:           big.NewInt(1),
+					EffectiveGasPrice: big.NewInt(10),
+				},
+			},
+			{
+				Hash:  hash("3"),
+				From:  addr("C"),
+				To:    to("D"),
+				Value: big.NewInt(1),
+				Type:  entities.TxTypeBlob,
+				Receipt: &entities.Receipt{
+					GasUsed:           big.NewInt(1),
+					EffectiveGasPrice: big.NewInt(12),
+					BlobGasUsed:       big.NewInt(2),
+					BlobGasPrice:      big.NewInt(3),
+				},
+			},
+		},
+	}
+
+	report, err := ethInteractor.walletsDeltas(
+		context.TODO(),
+		blockTransfers(block, AccountingFees),
+	)
+	if err != nil {
+		t.Fatalf("error: %s\n", err.Error())
+	}
+
+	// Dynamic fee: 30 + 10 paid, 20 + 10 burned. Blob: 12 + 6 paid, 10 + 6 burned
+	expected := map[entities.TxType][4]int64{
+		entities.TxTypeDynamicFee: {2, 105, 10, 30},
+		entities.TxTypeBlob:       {1, 1, 2, 16},
+	}
+
+	if len(report.TxTypes) != len(expected) {
+		t.Fatalf("invalid tx types: %d | Expected: %d", len(report.TxTypes), len(expected))
+	}
+
+	for tt, e := range expected {
+		s, ok := report.TxTypes[tt]
+		if !ok {
+			t.Fatalf("missing %s stats", tt)
+		}
+
+		if got := [4]int64{int64(s.Transactions), s.Value.Int64(), s.Tips.Int64(), s.Burned.Int64()}; got != e {
+			t.Fatalf("invalid %s stats: %v | Expected: %v", tt, got, e)
+		}
+	}
+}
+
+func TestTracesAccounting(t *testing.T) {
+	ethInteractor := &ethInteractor{log: slog.Default()}
+
+	// B forwards 30 to C and fails to send 50 to D. C self destructs to E
+	trace := new(entities.CallFrame)
+
+	err := json.Unmarshal([]byte(withAddresses(`{
+		"type": "CALL", "from": "A", "to": "B", "value": "0x64",
+		"calls": [
+			{"type": "STATICCALL", "from": "B", "to": "O"},
+			{
+				"type": "CALL", "from": "B", "to": "C", "value": "0x1e",
+				"calls": [{"type": "SELFDESTRUCT", "from": "C", "to": "E", "value": "0xa"}]
+			},
+			{
+				"type": "CALL", "from": "B", "to": "D", "value": "0x32", "error": "execution reverted",
+				"calls": [{"type": "CALL", "from": "D", "to": "F", "value": "0x32"}]
+			}
+		]
+	}`)), trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// G fails to send 5 to H, so H does not forward 5 to I
+	reverted := new(entities.CallFrame)
+
+	err = json.Unmarshal([]byte(withAddresses(`{
+		"type": "CALL", "from": "G", "to": "H", "value": "0x5", "error": "execution reverted",
+		"calls": [{"type": "CALL", "from": "H", "to": "I", "value": "0x5"}]
+	}`)), reverted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := &entities.Block{
+		Transactions: []*entities.Transaction{
+			{Hash: hash("1"), From: addr("A"), To: to("B"), Value: big.NewInt(100), Trace: trace},
+			{Hash: hash("2"), From: addr("G"), To: to("H"), Value: big.NewInt(5), Trace: reverted},
+		},
+	}
+
+	report, err := ethInteractor.walletsDeltas(
+		context.TODO(),
+		blockTransfers(block, AccountingTraces),
+	)
+	if err != nil {
+		t.Fatalf("error: %s\n", err.Error())
+	}
+
+	expected := map[entities.Address]int64{
+		addr("A"): -100,
+		addr("B"): 70,
+		addr("C"): 20,
+		addr("E"): 10,
+	}
+
+	if len(report.Wallets) != len(expected) {
+		t.Fatalf("invalid wallets count: %d | Expected: %d", len(report.Wallets), len(expected))
+	}
+
+	for _, w := range report.Wallets {
+		if w.Delta.Int64() != expected[w.Address] {
+			t.Fatalf("invalid delta of %s: %s | Expected: %d", w.Address, w.Delta, expected[w.Address])
+		}
+	}
+}
+
+func TestRewardsAndWithdrawalsAccounting(t *testing.T) {
+	ethInteractor := &ethInteractor{log: slog.Default()}
+
+	block := &entities.Block{
+		BaseFeePerGas: big.NewInt(10),
+		Miner:         addr("M"),
+		Transactions: []*entities.Transaction{
+			{
+				Hash:  hash("1"),
+				From:  addr("A"),
+				To:    to("B"),
+				Value: big.NewInt(100),
+				Receipt: &entities.Receipt{
+					GasUsed:           big.NewInt(2),
+					EffectiveGasPrice: big.NewInt(15),
+					BlobGasUsed:       new(big.Int),
+					BlobGasPrice:      new(big.Int),
+				},
+			},
+		},
+		Withdrawals: []*entities.Withdrawal{
+			{Address: addr("V"), Amount: big.NewInt(3)},
+			{Address: addr("B"), Amount: big.NewInt(1)},
+		},
+	}
+
+	var transfers []*entities.Transfer
+
+	accounting := AccountingRewards | AccountingWithdrawals
+
+	for _, tx := range block.Transactions {
+		transfers = append(transfers, transactionTransfers(block, tx, accounting)...)
+	}
+
+	transfers = append(transfers, withdrawalTransfers(block, accounting)...)
+
+	transfersChan := make(chan *entities.Transfer, len(transfers))
+	for _, tr := range transfers {
+		transfersChan <- tr
+	}
+
+	close(transfersChan)
+
+	report, err := ethInteractor.walletsDeltas(context.TODO(), transfersChan)
+	if err != nil {
+		t.Fatalf("error: %s\n", err.Error())
+	}
+
+	// The sender is not debited with fees. The fee recipient earns 10 of tips
+	expected := map[entities.Address]int64{
+		addr("A"): -100,
+		addr("B"): 1_000_000_100,
+		addr("M"): 10,
+		addr("V"): 3_000_000_000,
+	}
+
+	if len(report.Wallets) != len(expected) {
+		t.Fatalf("invalid wallets count: %d | Expected: %d", len(report.Wallets), len(expected))
+	}
+
+	for _, w := range report.Wallets {
+		if w.Delta.Int64() != expected[w.Address] {
+			t.Fatalf("invalid delta of %s: %s | Expected: %d", w.Address, w.Delta, expected[w.Address])
+		}
+	}
+
+	if report.Burned.Sign() != 0 {
+		t.Fatalf("invalid burned amount: %s | Expected: 0", report.Burned)
+	}
+}
+
+func TestContractCreation(t *testing.T) {
+	client := newNodeClientMock(30, true)
+
+	// Transaction of block 29 deploys a contract
+	client.blocks[entities.NewBlockNumber(big.NewInt(29))].Transactions[0].To = nil
+
+	// Transaction of block 28 fails to deploy a contract, so its value stays with the sender
+	failed := client.blocks[entities.NewBlockNumber(big.NewInt(28))].Transactions[0]
+
+	failed.To = nil
+	client.reverted[failed.Hash] = true
+
+	ethInteractor := NewEthInteractor(slog.Default(), client)
+
+	report, err := ethInteractor.TopChangedAddresses(
+		context.TODO(),
+		Query{NumBlocks: 2, Limit: 10},
+	)
+	if err != nil {
+		t.Fatalf("error: %s\n", err.Error())
+	}
+
+	expected := map[entities.Address]struct {
+		Delta   int64
+		Created bool
+	}{
+		addr("A"):           {Delta: -30},
+		contractAddress(29): {Delta: 30, Created: true},
+	}
+
+	if len(report.Wallets) != len(expected) {
+		t.Fatalf("invalid wallets count: %d | Expected: %d", len(report.Wallets), len(expected))
+	}
+
+	for _, w := range report.Wallets {
+		e, ok := expected[w.Address]
+		if !ok || w.Delta.Int64() != e.Delta || w.CreatedContract != e.Created {
+			t.Fatalf("invalid wallet %s: %s, created: %v | Expected: %+v", w.Address, w.Delta, w.CreatedContract, e)
+		}
+	}
+}
+
+func TestRevertedValue(t *testing.T) {
+	client := newNodeClientMock(30, true)
+
+	// Transaction of block 29 reverts, so value only accounting must fetch its receipt
+	client.reverted[client.blocks[entities.NewBlockNumber(big.NewInt(29))].Transactions[0].Hash] = true
+
+	ethInteractor := NewEthInteractor(slog.Default(), client)
+
+	report, err := ethInteractor.TopChangedAddresses(
+		context.TODO(),
+		Query{NumBlocks: 2, Limit: 10},
+	)
+	if err != nil {
+		t.Fatalf("error: %s\n", err.Error())
+	}
+
+	// Only 29 wei of block 28 are transferred
+	expected := map[entities.Address]int64{
+		addr("A"): -29,
+		addr("B"): 29,
+	}
+
+	if len(report.Wallets) != len(expected) {
+		t.Fatalf("invalid wallets count: %d | Expected: %d", len(report.Wallets), len(expected))
+	}
+
+	for _, w := range report.Wallets {
+		if w.Delta.Int64() != expected[w.Address] {
+			t.Fatalf("invalid delta of %s: %s | Expected: %d", w.Address, w.Delta, expected[w.Address])
+		}
+	}
+}
+
 func BenchmarkAddressWithBiggestDelta(b *testing.B) {
 	ethInteractor := &ethInteractor{log: slog.Default()}
 
@@ -44,41 +486,50 @@ func BenchmarkAddressWithBiggestDelta(b *testing.B) {
 	for i := 0; i < 20000; i++ {
 		txs[i] = &entities.Transaction{
 			Value: big.NewInt(rand.Int63()),
-			From:  RandStringRunes(rand.Intn(1000)),
-			To:    RandStringRunes(rand.Intn(1000)),
+			From:  addr(fmt.Sprint(rand.Intn(1000))),
+			To:    to(fmt.Sprint(rand.Intn(1000))),
 		}
 	}
 
-	txCh := make(chan *entities.Transaction, len(txs))
-
-	for _, txs := range txs {
-		txCh <- txs
-	}
-
-	close(txCh)
-
-	_, err := ethInteractor.addressWithBiggestDelta(context.TODO(), txCh)
+	_, err := ethInteractor.walletsDeltas(
+		context.TODO(),
+		blockTransfers(&entities.Block{Transactions: txs}, 0),
+	)
 	if err != nil {
 		b.Fatalf("error: %s\n", err.Error())
 	}
 }
 
-var letterRunes = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+// blockTransfers returns a closed channel filled with the block transfers
+func blockTransfers(block *entities.Block, accounting Accounting) chan *entities.Transfer {
+	var transfers []*entities.Transfer
 
-func RandStringRunes(n int) string {
-	b := make([]rune, n)
+	for _, tx := range block.Transactions {
+		transfers = append(transfers, transactionTransfers(block, tx, accounting)...)
+	}
+
+	ch := make(chan *entities.Transfer, len(transfers))
 
-	for i := range b {
-		b[i] = letterRunes[rand.Intn(len(letterRunes))]
+	for _, tr := range transfers {
+		ch <- tr
 	}
 
-	return string(b)
+	close(ch)
+
+	return ch
+}
+
+// withAddresses replaces quoted single letter names of the JSON with test addresses
+func withAddresses(s string) string {
+	return regexp.MustCompile(`"[A-Z]"`).ReplaceAllStringFunc(s, func(name string) string {
+		return `"` + addr(name[1:2]).Hex() + `"`
+	})
 }
 
 type TestCase struct {
 	Title          string
 	Block          *entities.Block
-	ExpectedResult []string
+	ExpectedResult entities.Address
 }
 
 var tests []TestCase = []TestCase{
@@ -87,90 +538,90 @@ var tests []TestCase = []TestCase{
 		Block: &entities.Block{
 			Transactions: []*entities.Transaction{
 				{
-					From:  "A",
-					To:    "B", // + 1000
+					From:  addr("A"),
+					To:    to("B"), // + 1000
 					Value: big.NewInt(1000),
 				},
 				{
-					From:  "F", // -90
-					To:    "A", // -10
+					From:  addr("F"), // -90
+					To:    to("A"),   // -10
 					Value: big.NewInt(1000),
 				},
 				{
-					From:  "A",
-					To:    "B", // +10
+					From:  addr("A"),
+					To:    to("B"), // +10
 					Value: big.NewInt(10),
 				},
 				{
-					From:  "A",
-					To:    "B", // +15
+					From:  addr("A"),
+					To:    to("B"), // +15
 					Value: big.NewInt(15),
 				},
 			},
 		},
-		ExpectedResult: []string{"B"},
+		ExpectedResult: addr("B"),
 	},
 	{
 		Title: "1 block, 0 wallets, 0 txs",
 		Block: &entities.Block{
 			Transactions: []*entities.Transaction{},
 		},
-		ExpectedResult: []string{""},
+		ExpectedResult: entities.Address{},
 	},
 	{
 		Title: "1 block, 3 wallets, 3 txs. Txs are negative",
 		Block: &entities.Block{
 			Transactions: []*entities.Transaction{
 				{
-					From:  "A",
-					To:    "B",
+					From:  addr("A"),
+					To:    to("B"),
 					Value: big.NewInt(-10),
 				},
 				{
-					From:  "A",
-					To:    "B",
+					From:  addr("A"),
+					To:    to("B"),
 					Value: big.NewInt(-1000),
 				},
 				{
-					From:  "F",
-					To:    "A",
+					From:  addr("F"),
+					To:    to("A"),
 					Value: big.NewInt(-1000),
 				},
 				{
-					From:  "A",
-					To:    "B",
+					From:  addr("A"),
+					To:    to("B"),
 					Value: big.NewInt(-15),
 				},
 			},
 		},
-		ExpectedResult: []string{"B"},
+		ExpectedResult: addr("B"),
 	},
 	{
-		Title: "1 block, 2 wallets, 3 txs. Both are equal",
+		Title: "1 block, 2 wallets, 4 txs. Both are equal, the lower address wins",
 		Block: &entities.Block{
 			Transactions: []*entities.Transaction{
 				{
-					From:  "A",
-					To:    "B",
+					From:  addr("A"),
+					To:    to("B"),
 					Value: big.NewInt(-10),
 				},
 				{
-					From:  "A",
-					To:    "B",
+					From:  addr("A"),
+					To:    to("B"),
 					Value: big.NewInt(50),
 				},
 				{
-					From:  "B",
-					To:    "A",
+					From:  addr("B"),
+					To:    to("A"),
 					Value: big.NewInt(10),
 				},
 				{
-					From:  "A",
-					To:    "B",
+					From:  addr("A"),
+					To:    to("B"),
 					Value: big.NewInt(-15),
 				},
 			},
 		},
-		ExpectedResult: []string{"B", "A"},
+		ExpectedResult: addr("A"),
 	},
 }
@@ -0,0 +1,28 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrorBatchNotSupported is thrown by NodeClient when
+	// the node provider does not support JSON rpc batch requests
+	ErrorBatchNotSupported = errors.New("batch requests are not supported")
+
+	// ErrorInvalidRange is thrown when the query blocks window is invalid
+	ErrorInvalidRange = errors.New("invalid blocks range")
+
+	// ErrorInvalidQuery is thrown when the query parameters can not be combined
+	ErrorInvalidQuery = errors.New("invalid query")
+)
+
+// MissingBlocksError is thrown in strict mode when some
+// blocks of the window could not be processed
+type MissingBlocksError struct {
+	Missing []uint64
+}
+
+func (e *MissingBlocksError) Error() string {
+	return fmt.Sprintf("error %d blocks could not be processed: %v", len(e.Missing), e.Missing)
+}
@@ -2,11 +2,13 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math/big"
 	"runtime"
 	"sync"
+	"time"
 
 	"github.com/alitto/pond"
 	"github.com/optclblast/blk/internal/entities"
@@ -17,114 +19,283 @@ import (
 // EthInteractor is core component of the system.
 // Here all the data processing magic happens
 type EthInteractor interface {
-	// MostChangedWalletAddress returns the address of the wallet whose balance
-	// delta was the highest among other wallets participating in transactions
-	// from numBlocks blocks to the HEAD block.
-	MostChangedAddress(ctx context.Context, numBlocks int) (string, error)
+	// TopChangedAddresses returns up to q.Limit wallets whose balance deltas were
+	// the highest among other wallets participating in transactions
+	// in the query blocks window. Wallets are ordered from the
+	// highest absolute delta to the lowest. The report contains the window
+	// coverage. In strict mode MissingBlocksError is returned if any block
+	// could not be processed.
+	TopChangedAddresses(ctx context.Context, q Query) (*entities.DeltaReport, error)
+
+	// AddressDelta returns the address balance delta in the query blocks window
+	// along with every contributing transfer and the running total. Transfers
+	// are taken from the same stream TopChangedAddresses aggregates. Only the
+	// window, Strict and Token fields of the query are used
+	AddressDelta(ctx context.Context, address entities.Address, q Query) (*entities.AddressDelta, error)
+
+	// Start runs background workers until ctx is done
+	Start(ctx context.Context)
 }
 
 // ethInteractor is an EthInteractor implementation
 type ethInteractor struct {
-	log    *slog.Logger
-	client NodeClient
+	log        *slog.Logger
+	client     NodeClient
+	accounting Accounting
+	batchSize  int
+	maxWindow  int
+
+	followInterval time.Duration
+	follower       *headFollower
+
+	// Configured address filters
+	include addressSet
+	exclude addressSet
+	kinds   *kindsCache
+	labels  LabelRegistry
+	prices  PriceSource
 }
 
 // NewEthInteractor return new NewEthInteractor instance
 func NewEthInteractor(
 	log *slog.Logger,
 	client NodeClient,
+	opts ...Option,
 ) EthInteractor {
-	return &ethInteractor{
-		log:    log,
-		client: client,
+	t := &ethInteractor{
+		log:       log,
+		client:    client,
+		batchSize: defaultBatchSize,
+		maxWindow: DefaultMaxWindow,
+		kinds:     newKindsCache(),
+	}
+
+	// Apply options
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	if t.followInterval > 0 {
+		t.follower = newHeadFollower(t, t.followInterval, t.maxWindow)
+	}
+
+	return t
+}
+
+// Start runs the head follower if it is enabled
+func (t *ethInteractor) Start(ctx context.Context) {
+	if t.follower != nil {
+		go t.follower.run(ctx)
 	}
 }
 
 // Standard number of workers in all kind of pools
 var defaultWorkersNum = runtime.GOMAXPROCS(0) * 2
 
-func (t *ethInteractor) MostChangedAddress(
+func (t *ethInteractor) TopChangedAddresses(
+	ctx context.Context,
+	q Query,
+) (*entities.DeltaReport, error) {
+	// Head relative windows may be already computed by the follower
+	if t.follower != nil && !q.tokens() && q.headRelative() {
+		if report, ok := t.follower.report(q.NumBlocks, q.Rank, t.candidatesLimit(q)); ok {
+			return t.finishReport(ctx, q, report)
+		}
+	}
+
+	// Begin a transfers data stream
+	tracker, transfersChan, err := t.streamWindow(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	// Handle transfers stream and calculate the result
+	report, err := t.walletsDeltas(ctx, transfersChan)
+	if err != nil {
+		return nil, fmt.Errorf("error fetch wallets. %w", err)
+	}
+
+	report.Coverage = tracker.coverage()
+
+	if err := t.checkCoverage(q, report.Coverage); err != nil {
+		return nil, err
+	}
+
+	return t.finishReport(ctx, q, report)
+}
+
+// streamWindow resolves the query blocks window and begins a stream of its
+// transfers. Processed blocks are marked in the returned tracker
+func (t *ethInteractor) streamWindow(
 	ctx context.Context,
-	numBlocks int,
-) (string, error) {
+	q Query,
+) (*coverageTracker, chan *entities.Transfer, error) {
 	// We need to fetch current head block
 	head, err := t.client.LastBlockNumber(ctx)
 	if err != nil {
-		return "", fmt.Errorf("error fetch last block number. %w", err)
+		return nil, nil, fmt.Errorf("error fetch last block number. %w", err)
 	}
 
 	t.log.Debug(
-		"most_changed_address",
+		"stream_window",
 		slog.String("head block number", (string)(head)),
-		slog.Int("num blocks parameter", numBlocks),
+		slog.Int("num blocks parameter", q.NumBlocks),
+		slog.Int("limit parameter", q.Limit),
+		slog.Bool("strict parameter", q.Strict),
 	)
 
 	headBlockNumber, err := head.ToInt()
 	if err != nil {
-		return "", fmt.Errorf("error map last block number to numeric. %w", err)
+		return nil, nil, fmt.Errorf("error map last block number to numeric. %w", err)
+	}
+
+	from, to, err := t.resolveWindow(ctx, q, headBlockNumber.Uint64())
+	if err != nil {
+		return nil, nil, fmt.Errorf("error resolve blocks window. %w", err)
+	}
+
+	tracker := newCoverageTracker(from, to)
+
+	transfersChan := make(chan *entities.Transfer, defaultWorkersNum)
+
+	switch {
+	case q.AllTokens:
+		t.streamTokenTransfers(ctx, from, to, nil, tracker, transfersChan)
+	case q.tokens():
+		t.streamTokenTransfers(ctx, from, to, []entities.Address{q.Token}, tracker, transfersChan)
+	default:
+		t.streamTransfers(ctx, from, to, tracker, transfersChan)
+	}
+
+	return tracker, transfersChan, nil
+}
+
+// checkCoverage returns MissingBlocksError in strict mode if the window
+// coverage is incomplete. Otherwise the partial window is logged
+func (t *ethInteractor) checkCoverage(q Query, coverage *entities.Coverage) error {
+	if coverage.Complete() {
+		return nil
+	}
+
+	if q.Strict {
+		return &MissingBlocksError{Missing: coverage.Missing}
 	}
 
-	txChan := make(chan *entities.Transaction, defaultWorkersNum)
+	t.log.Warn(
+		"partial blocks window",
+		slog.Int("requested", coverage.Requested),
+		slog.Int("missing", len(coverage.Missing)),
+	)
 
-	// Begin a transactions data stream
-	t.streamTransactions(ctx, headBlockNumber, numBlocks, txChan)
+	return nil
+}
 
-	// Handle transactions stream and calculate the result
-	walletAddress, err := t.addressWithBiggestDelta(ctx, txChan)
+// candidatesLimit returns an amount of the highest delta wallets the query result
+// is selected from. Filtered queries are selected from all the wallets
+func (t *ethInteractor) candidatesLimit(q Query) int {
+	if q.filtered() || len(t.include) > 0 || len(t.exclude) > 0 {
+		return 0
+	}
+
+	return q.Limit
+}
+
+// finishReport selects the report wallets matching the query, verifies them if
+// requested and values their deltas in USD
+func (t *ethInteractor) finishReport(
+	ctx context.Context,
+	q Query,
+	report *entities.DeltaReport,
+) (*entities.DeltaReport, error) {
+	wallets, err := t.selectWallets(ctx, report.Wallets, q)
 	if err != nil {
-		return "", fmt.Errorf("error fetch wallets. %w", err)
+		return nil, fmt.Errorf("error select wallets. %w", err)
 	}
 
-	return walletAddress, nil
+	report.Wallets = wallets
+
+	if q.Verify {
+		t.verifyWallets(ctx, report)
+	}
+
+	t.valueWallets(ctx, report.Wallets)
+
+	return report, nil
 }
 
-func (t *ethInteractor) addressWithBiggestDelta(
+// walletsDeltas consumes transfers stream and returns all the wallets
+// participating in it with their balance deltas
+func (t *ethInteractor) walletsDeltas(
 	ctx context.Context,
-	txChan chan *entities.Transaction,
-) (string, error) {
-	outChan := make(chan string, 1)
+	transfersChan chan *entities.Transfer,
+) (*entities.DeltaReport, error) {
+	outChan := make(chan *entities.DeltaReport, 1)
 
 	go func() {
 		defer func() {
 			if panic := recover(); panic != nil {
-				t.log.Error("addressWithBiggestDelta", slog.Any("panic", panic))
+				t.log.Error("walletsDeltas", slog.Any("panic", panic))
 				return
 			}
 		}()
 
-		// map [Wallet address => Delta]
-		addresses := cmap.New[*big.Int]()
+		// map [Wallet key => Wallet]
+		addresses := cmap.NewWithCustomShardingFunction[walletKey, *entities.Wallet](walletKeyShard)
+
+		// Burned amount and transactions totals by type per worker
+		burned := make([]*big.Int, defaultWorkersNum)
+		txTypes := make([]entities.TxTypes, defaultWorkersNum)
 
 		var wg sync.WaitGroup
 
-		// Fill the map with address / delta pairs
+		// Fill the map with address / wallet pairs
 		for i := 0; i < defaultWorkersNum; i++ {
 			// Run a writer worker
 			wg.Add(1)
 
+			burned[i] = new(big.Int)
+			txTypes[i] = make(entities.TxTypes)
+
 			go func() {
 				defer wg.Done()
 
-				t.appendAddressDeltaWorker(&addresses, txChan)
+				t.appendAddressDeltaWorker(&addresses, burned[i], txTypes[i], transfersChan)
 			}()
 		}
 
 		wg.Wait()
 
-		outChan <- biggestDeltaAddres(addresses.Items())
+		report := &entities.DeltaReport{
+			Wallets: make(entities.Wallets, 0, addresses.Count()),
+			Burned:  new(big.Int),
+			TxTypes: make(entities.TxTypes),
+		}
+
+		for _, w := range addresses.Items() {
+			report.Wallets = append(report.Wallets, w)
+		}
+
+		for i := range burned {
+			report.Burned.Add(report.Burned, burned[i])
+			report.TxTypes.Add(txTypes[i])
+		}
+
+		outChan <- report
 	}()
 
 	select {
 	case out := <-outChan:
 		return out, nil
 	case <-ctx.Done():
-		return "", ctx.Err()
+		return nil, ctx.Err()
 	}
 }
 
 func (t *ethInteractor) appendAddressDeltaWorker(
-	cmp *cmap.ConcurrentMap[string, *big.Int],
-	txsChan <-chan *entities.Transaction,
+	cmp *cmap.ConcurrentMap[walletKey, *entities.Wallet],
+	burned *big.Int,
+	txTypes entities.TxTypes,
+	transfersChan <-chan *entities.Transfer,
 ) {
 	defer func() {
 		if panic := recover(); panic != nil {
@@ -133,99 +304,113 @@ func (t *ethInteractor) appendAddressDeltaWorker(
 		}
 	}()
 
-	for t := range txsChan {
-		deltaFrom, ok := cmp.Get(t.From)
-		if !ok {
-			deltaFrom = new(big.Int)
-		}
+	for t := range transfersChan {
+		// Upsert callback is called under the shard lock, so concurrent
+		// updates of the same wallet are safe
+		if t.HasSender() {
+			cmp.Upsert(walletKey{t.From, t.Token}, nil, func(exist bool, w, _ *entities.Wallet) *entities.Wallet {
+				if !exist {
+					w = entities.NewWallet(t.From)
+					w.Token = t.Token
+				}
 
-		cmp.Set(t.From, deltaFrom.Sub(deltaFrom, t.Value))
+				w.ApplySent(t)
 
-		deltaTo, ok := cmp.Get(t.To)
-		if !ok {
-			deltaTo = new(big.Int)
+				return w
+			})
 		}
 
-		cmp.Set(t.To, deltaTo.Add(deltaTo, t.Value))
-	}
-}
-
-// Returns an address of a wallet with balances mod|delta| is the highest
-func biggestDeltaAddres(
-	set map[string]*big.Int,
-) string {
-	if len(set) == 0 {
-		return ""
-	}
+		if t.Kind == entities.TransferKindBurn {
+			burned.Add(burned, t.Value)
+		}
 
-	i := 0
+		txTypes.Apply(t)
 
-	// Build wallets array
-	wallets := make(entities.Wallets, len(set))
-	for addr, dlt := range set {
-		wallets[i] = &entities.Wallet{
-			Address: addr,
-			Delta:   dlt.Abs(dlt),
+		if !t.HasRecipient() {
+			continue
 		}
 
-		i++
-	}
+		cmp.Upsert(walletKey{t.To, t.Token}, nil, func(exist bool, w, _ *entities.Wallet) *entities.Wallet {
+			if !exist {
+				w = entities.NewWallet(t.To)
+				w.Token = t.Token
+			}
 
-	// Sort
-	wallets.Sort()
+			w.ApplyReceived(t)
 
-	// Return an address with highest delta
-	return wallets[len(wallets)-1].Address
+			return w
+		})
+	}
 }
 
-const fetchWorkersPoolSize = 4
+// DefaultMaxWindow is a default maximum amount of blocks in a query window.
+// The head follower keeps the blocks of a window of this size
+const DefaultMaxWindow = 150
+
+const (
+	fetchWorkersPoolSize = 4
+	defaultBatchSize     = 10
+)
 
-// streamTransactions fetches blocks from getblock node API and
-// dispatches related transaction into a dedicated channel for
-// other workers to process.
-// The channels used by streamTransactions will be closed
+// streamTransfers fetches blocks from the node API and
+// dispatches related transfers into a dedicated channel for
+// other workers to process. Blocks of the inclusive [from, to] window
+// are fetched from the newest to the oldest and verified to belong to the
+// same chain before processing. Processed blocks are marked in tracker.
+// The channels used by streamTransfers will be closed
 // internally.
-func (t *ethInteractor) streamTransactions(
+func (t *ethInteractor) streamTransfers(
 	ctx context.Context,
-	headBlock *big.Int,
-	numBlocks int,
-	txChan chan<- *entities.Transaction,
+	from, to uint64,
+	tracker *coverageTracker,
+	transfersChan chan<- *entities.Transfer,
 ) {
-	blockToFetch := new(big.Int).Set(headBlock)
+	numBlocks := int(to - from + 1)
+	blockToFetch := new(big.Int).SetUint64(to)
 	blocksChan := make(chan *entities.Block, numBlocks)
 	fetchPool := pond.New(fetchWorkersPoolSize, numBlocks)
 
-	var fetchWg sync.WaitGroup
+	var (
+		fetchWg sync.WaitGroup
+		fetchMu sync.Mutex
+		// map [Block number => Block]
+		fetched = make(map[uint64]*entities.Block, numBlocks)
+	)
+
+	for i := 0; i < numBlocks; i += t.batchSize {
+		batch := make([]entities.BlockNumber, 0, t.batchSize)
 
-	for i := 0; i < numBlocks; i++ {
-		blockNumber := entities.BlockNumber("0x" + blockToFetch.Text(16))
+		for j := i; j < numBlocks && j < i+t.batchSize; j++ {
+			batch = append(batch, entities.NewBlockNumber(blockToFetch))
+			blockToFetch.Sub(blockToFetch, big.NewInt(1))
+		}
 
 		fetchWg.Add(1)
 		fetchPool.Submit(func() {
 			defer fetchWg.Done()
 
-			block, err := t.client.BlockInfoByNumber(
-				ctx,
-				blockNumber,
-			)
-			if err != nil {
-				t.log.Error(
-					"error fetch block info",
-					logger.Err(err),
-					slog.Any("block number", blockNumber),
-				)
+			blocks := t.fetchBlocks(ctx, batch)
 
-				return
-			}
+			fetchMu.Lock()
+			defer fetchMu.Unlock()
 
-			blocksChan <- block
+			for _, block := range blocks {
+				fetched[block.Number.Uint64()] = block
+			}
 		})
-
-		blockToFetch.Sub(blockToFetch, big.NewInt(1))
 	}
 
 	go func() {
 		fetchWg.Wait()
+
+		// Blocks of different forks must not be mixed
+		t.verifyChain(ctx, fetched, from, to)
+
+		for num, block := range fetched {
+			tracker.add(num)
+			blocksChan <- block
+		}
+
 		close(blocksChan)
 	}()
 
@@ -234,19 +419,231 @@ func (t *ethInteractor) streamTransactions(
 	var processWg sync.WaitGroup
 
 	go func() {
-		dispatchBlockTransactions(&processWg, processPool, blocksChan, txChan)
+		dispatchBlockTransfers(&processWg, processPool, blocksChan, transfersChan, t.accounting)
 
 		processWg.Wait()
-		close(txChan)
+		close(transfersChan)
 	}()
 }
 
-// Dispatches blocks from blocksChan into txsChan
-func dispatchBlockTransactions(
+// fetchBlocks fetches blocks with all the data required by the accounting mode.
+// A batch request is used if the node provider supports it, otherwise blocks are
+// fetched one by one. Blocks of a failed batch are fetched one by one as well,
+// so a single broken block does not drop the whole batch. Blocks that can not
+// be fetched are logged and skipped.
+func (t *ethInteractor) fetchBlocks(
+	ctx context.Context,
+	nums []entities.BlockNumber,
+) []*entities.Block {
+	if len(nums) > 1 {
+		blocks, err := t.fetchBlocksBatch(ctx, nums)
+		if err == nil {
+			return blocks
+		}
+
+		if errors.Is(err, ErrorBatchNotSupported) {
+			t.log.Debug("batch requests are not supported. fetching blocks one by one")
+		} else {
+			t.log.Warn(
+				"error fetch blocks batch. fetching blocks one by one",
+				logger.Err(err),
+				slog.Any("block numbers", nums),
+			)
+		}
+	}
+
+	blocks := make([]*entities.Block, 0, len(nums))
+
+	for _, num := range nums {
+		block, err := t.fetchBlock(ctx, num)
+		if err != nil {
+			t.log.Error(
+				"error fetch block info",
+				logger.Err(err),
+				slog.Any("block number", num),
+			)
+
+			continue
+		}
+
+		blocks = append(blocks, block)
+	}
+
+	return blocks
+}
+
+// fetchBlocksBatch fetches blocks using a single batch request
+func (t *ethInteractor) fetchBlocksBatch(
+	ctx context.Context,
+	nums []entities.BlockNumber,
+) ([]*entities.Block, error) {
+	blocks, err := t.client.BlocksByNumbers(ctx, nums)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, block := range blocks {
+		if err := t.attachReceipts(ctx, nums[i], block); err != nil {
+			return nil, err
+		}
+
+		if err := t.attachTraces(ctx, nums[i], block); err != nil {
+			return nil, err
+		}
+
+		if err := t.attachCreationReceipts(ctx, block); err != nil {
+			return nil, err
+		}
+	}
+
+	return blocks, nil
+}
+
+// fetchBlock fetches a block with all the data required by the accounting mode
+func (t *ethInteractor) fetchBlock(
+	ctx context.Context,
+	num entities.BlockNumber,
+) (*entities.Block, error) {
+	block, err := t.client.BlockInfoByNumber(ctx, num)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.attachReceipts(ctx, num, block); err != nil {
+		return nil, err
+	}
+
+	if err := t.attachTraces(ctx, num, block); err != nil {
+		return nil, err
+	}
+
+	if err := t.attachCreationReceipts(ctx, block); err != nil {
+		return nil, err
+	}
+
+	return block, nil
+}
+
+// attachReceipts fetches block receipts and attaches them to the block
+// transactions if the accounting mode requires them
+func (t *ethInteractor) attachReceipts(
+	ctx context.Context,
+	num entities.BlockNumber,
+	block *entities.Block,
+) error {
+	if !t.needsReceipts(block) {
+		return nil
+	}
+
+	receipts, err := t.client.BlockReceipts(ctx, num)
+	if err != nil {
+		return fmt.Errorf("error fetch block receipts. %w", err)
+	}
+
+	// map [Tx hash => Receipt]
+	byHash := make(map[entities.Hash]*entities.Receipt, len(receipts))
+	for _, r := range receipts {
+		byHash[r.TransactionHash] = r
+	}
+
+	for _, tx := range block.Transactions {
+		receipt, ok := byHash[tx.Hash]
+		if !ok {
+			return fmt.Errorf("error receipt for tx %s not found", tx.Hash)
+		}
+
+		tx.Receipt = receipt
+	}
+
+	return nil
+}
+
+// needsReceipts reports whether the block transfers depend on its receipts.
+// Fees require receipts. Value of a reverted transaction is not transferred,
+// so receipts statuses are required for value transfers unless the traces
+// tell the reverted transactions
+func (t *ethInteractor) needsReceipts(block *entities.Block) bool {
+	if t.accounting.Has(AccountingFees) || t.accounting.Has(AccountingRewards) {
+		return true
+	}
+
+	if t.accounting.Has(AccountingTraces) {
+		return false
+	}
+
+	for _, tx := range block.Transactions {
+		if tx.Value != nil && tx.Value.Sign() != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// attachCreationReceipts fetches receipts of the contract creation transactions
+// that have no receipt attached. Created contract addresses are in the receipts
+func (t *ethInteractor) attachCreationReceipts(
+	ctx context.Context,
+	block *entities.Block,
+) error {
+	for _, tx := range block.Transactions {
+		if !tx.IsCreation() || tx.Receipt != nil {
+			continue
+		}
+
+		receipt, err := t.client.TransactionReceipt(ctx, tx.Hash)
+		if err != nil {
+			return fmt.Errorf("error fetch contract creation receipt. %w", err)
+		}
+
+		tx.Receipt = receipt
+	}
+
+	return nil
+}
+
+// attachTraces fetches block call traces and attaches them to the block
+// transactions if the accounting mode requires them
+func (t *ethInteractor) attachTraces(
+	ctx context.Context,
+	num entities.BlockNumber,
+	block *entities.Block,
+) error {
+	if !t.accounting.Has(AccountingTraces) {
+		return nil
+	}
+
+	traces, err := t.client.BlockTraces(ctx, num)
+	if err != nil {
+		return fmt.Errorf("error fetch block traces. %w", err)
+	}
+
+	if len(traces) != len(block.Transactions) {
+		return fmt.Errorf(
+			"error block %s has %d traces for %d txs",
+			num, len(traces), len(block.Transactions),
+		)
+	}
+
+	// Traces are ordered as the block transactions. Some clients omit tx hashes
+	for i, tx := range block.Transactions {
+		if !traces[i].TxHash.IsZero() && traces[i].TxHash != tx.Hash {
+			return fmt.Errorf("error trace of tx %s not found", tx.Hash)
+		}
+
+		tx.Trace = traces[i].Result
+	}
+
+	return nil
+}
+
+// Dispatches transfers of blocks from blocksChan into transfersChan
+func dispatchBlockTransfers(
 	wg *sync.WaitGroup,
 	pool *pond.WorkerPool,
 	blocksChan <-chan *entities.Block,
-	txsChan chan<- *entities.Transaction,
+	transfersChan chan<- *entities.Transfer,
+	accounting Accounting,
 ) {
 	for b := range blocksChan {
 		wg.Add(1)
@@ -254,8 +651,18 @@ func dispatchBlockTransactions(
 		pool.Submit(func() {
 			defer wg.Done()
 
+			num := b.Number.Uint64()
+
 			for _, tx := range b.Transactions {
-				txsChan <- tx
+				for _, tr := range transactionTransfers(b, tx, accounting) {
+					tr.BlockNumber, tr.BlockTime = num, b.Timestamp
+					transfersChan <- tr
+				}
+			}
+
+			for _, tr := range withdrawalTransfers(b, accounting) {
+				tr.BlockNumber, tr.BlockTime = num, b.Timestamp
+				transfersChan <- tr
 			}
 		})
 	}
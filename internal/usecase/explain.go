@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+// AddressDelta returns the address balance delta in the query blocks window
+// along with every contributing transfer in the chain order. The window is
+// resolved as in TopChangedAddresses. If q.Token is set, the token transfers
+// are explained instead of ETH ones. Deltas of all the tokens are not
+// supported, so ErrorInvalidQuery is returned if q.AllTokens is set. In
+// strict mode MissingBlocksError is returned if any block could not be processed
+func (t *ethInteractor) AddressDelta(
+	ctx context.Context,
+	address entities.Address,
+	q Query,
+) (*entities.AddressDelta, error) {
+	if q.AllTokens {
+		return nil, fmt.Errorf("error explain deltas of all the tokens. %w", ErrorInvalidQuery)
+	}
+
+	tracker, transfersChan, err := t.streamWindow(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	// Transfers of a block are dispatched in order by a single worker,
+	// so a stable sort by block number restores the chain order
+	var transfers []*entities.Transfer
+
+	for tr := range transfersChan {
+		if tr.Involves(address) {
+			transfers = append(transfers, tr)
+		}
+	}
+
+	sort.SliceStable(transfers, func(i, j int) bool {
+		return transfers[i].BlockNumber < transfers[j].BlockNumber
+	})
+
+	delta := entities.NewAddressDelta(address, q.Token)
+
+	for _, tr := range transfers {
+		delta.Apply(tr)
+	}
+
+	delta.Coverage = tracker.coverage()
+
+	if err := t.checkCoverage(q, delta.Coverage); err != nil {
+		return nil, err
+	}
+
+	return delta, nil
+}
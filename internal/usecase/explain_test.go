@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/big"
+	"testing"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+func TestAddressDelta(t *testing.T) {
+	client := newNodeClientMock(30, true)
+
+	// Block 28 also sends 5 wei back from B to A
+	block := client.blocks[entities.NewBlockNumber(big.NewInt(28))]
+	block.Transactions = append(block.Transactions, &entities.Transaction{
+		Hash:  hash("t28b"),
+		From:  addr("B"),
+		To:    to("A"),
+		Value: big.NewInt(5),
+	})
+
+	ethInteractor := NewEthInteractor(slog.Default(), client)
+
+	q := Query{NumBlocks: 3, Limit: 10}
+
+	delta, err := ethInteractor.AddressDelta(context.TODO(), addr("B"), q)
+	if err != nil {
+		t.Fatalf("error: %s\n", err.Error())
+	}
+
+	expected := []struct {
+		TxHash      entities.Hash
+		BlockNumber uint64
+		Direction   entities.Direction
+		Amount      int64
+		Total       int64
+	}{
+		{TxHash: hash("t27"), BlockNumber: 27, Direction: entities.DirectionIn, Amount: 28, Total: 28},
+		{TxHash: hash("t28"), BlockNumber: 28, Direction: entities.DirectionIn, Amount: 29, Total: 57},
+		{TxHash: hash("t28b"), BlockNumber: 28, Direction: entities.DirectionOut, Amount: 5, Total: 52},
+		{TxHash: hash("t29"), BlockNumber: 29, Direction: entities.DirectionIn, Amount: 30, Total: 82},
+	}
+
+	if len(delta.Contributions) != len(expected) {
+		t.Fatalf("invalid contributions: %d | Expected: %d", len(delta.Contributions), len(expected))
+	}
+
+	for i, e := range expected {
+		c := delta.Contributions[i]
+
+		if c.TxHash != e.TxHash ||
+			c.BlockNumber != e.BlockNumber ||
+			c.Counterparty == nil || *c.Counterparty != addr("A") ||
+			c.Direction != e.Direction ||
+			c.Amount.Int64() != e.Amount ||
+			c.Total.Int64() != e.Total {
+			t.Fatalf(
+				"invalid contribution #%d: %s %d %s %s %s %s | Expected: %v",
+				i, c.TxHash, c.BlockNumber, c.Counterparty, c.Direction, c.Amount, c.Total, e,
+			)
+		}
+	}
+
+	// The explained delta matches the aggregated one
+	report, err := ethInteractor.TopChangedAddresses(context.TODO(), q)
+	if err != nil {
+		t.Fatalf("error: %s\n", err.Error())
+	}
+
+	for _, w := range report.Wallets {
+		if w.Address != addr("B") {
+			continue
+		}
+
+		if w.Delta.Cmp(delta.Wallet.Delta) != 0 || w.TxCount != delta.Wallet.TxCount {
+			t.Fatalf(
+				"invalid delta: %s %d | Expected: %s %d",
+				delta.Wallet.Delta, delta.Wallet.TxCount, w.Delta, w.TxCount,
+			)
+		}
+	}
+
+	_, err = ethInteractor.AddressDelta(context.TODO(), addr("B"), Query{NumBlocks: 3, AllTokens: true})
+	if !errors.Is(err, ErrorInvalidQuery) {
+		t.Fatalf("invalid error: %v | Expected: %s", err, ErrorInvalidQuery)
+	}
+}
@@ -0,0 +1,502 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+// nodeClientMock is an in-memory NodeClient implementation
+type nodeClientMock struct {
+	mu sync.Mutex
+
+	head           int64
+	blocks         map[entities.BlockNumber]*entities.Block
+	batchSupported bool
+
+	// Transactions whose receipts have a failed status
+	reverted map[entities.Hash]bool
+
+	singleCalls int
+	batchCalls  int
+	codeCalls   int
+	headerCalls int
+}
+
+// newNodeClientMock returns a node client mock with a chain of numBlocks
+// blocks produced every 12 seconds, each containing a single transaction
+func newNodeClientMock(numBlocks int64, batchSupported bool) *nodeClientMock {
+	m := &nodeClientMock{
+		head:           numBlocks - 1,
+		blocks:         make(map[entities.BlockNumber]*entities.Block, numBlocks),
+		batchSupported: batchSupported,
+		reverted:       make(map[entities.Hash]bool),
+	}
+
+	for i := int64(0); i < numBlocks; i++ {
+		m.addBlock(i)
+	}
+
+	return m
+}
+
+// addBlock adds a block with a single transaction of i+1 wei
+func (m *nodeClientMock) addBlock(i int64) {
+	num := big.NewInt(i)
+	parentHash := hash(fmt.Sprint(i - 1))
+
+	if parent, ok := m.blocks[entities.NewBlockNumber(big.NewInt(i-1))]; ok {
+		parentHash = parent.Hash
+	}
+
+	m.blocks[entities.NewBlockNumber(num)] = &entities.Block{
+		Number:     num,
+		Timestamp:  time.Unix(i*12, 0),
+		Hash:       hash(fmt.Sprint(i)),
+		ParentHash: parentHash,
+		Transactions: []*entities.Transaction{
+			{
+				Hash:  hash(fmt.Sprintf("t%d", i)),
+				From:  addr("A"),
+				To:    to("B"),
+				Value: big.NewInt(i + 1),
+			},
+		},
+	}
+}
+
+// extend produces numBlocks new blocks on top of the head
+func (m *nodeClientMock) extend(numBlocks int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := int64(0); i < numBlocks; i++ {
+		m.head++
+		m.addBlock(m.head)
+	}
+}
+
+// reorg replaces depth blocks up to the head with blocks of another fork
+func (m *nodeClientMock) reorg(depth int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := m.head - depth + 1; i <= m.head; i++ {
+		num := big.NewInt(i)
+		parent := m.blocks[entities.NewBlockNumber(big.NewInt(i-1))]
+
+		m.blocks[entities.NewBlockNumber(num)] = &entities.Block{
+			Number:     num,
+			Timestamp:  time.Unix(i*12, 0),
+			Hash:       hash(fmt.Sprintf("%dr", i)),
+			ParentHash: parent.Hash,
+			Transactions: []*entities.Transaction{
+				{
+					Hash:  hash(fmt.Sprintf("t%dr", i)),
+					From:  addr("C"),
+					To:    to("D"),
+					Value: big.NewInt(i + 1),
+				},
+			},
+		}
+	}
+}
+
+func (m *nodeClientMock) LastBlockNumber(context.Context) (entities.BlockNumber, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return entities.NewBlockNumber(big.NewInt(m.head)), nil
+}
+
+func (m *nodeClientMock) BlockInfoByNumber(
+	_ context.Context,
+	num entities.BlockNumber,
+) (*entities.Block, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.singleCalls++
+
+	block, ok := m.blocks[num]
+	if !ok {
+		return nil, fmt.Errorf("block %s not found", num)
+	}
+
+	return block, nil
+}
+
+func (m *nodeClientMock) BlockHeaderByNumber(
+	_ context.Context,
+	num entities.BlockNumber,
+) (*entities.Block, error) {
+	m.mu.Lock()
+	m.headerCalls++
+	m.mu.Unlock()
+
+	switch num {
+	case entities.BlockTagSafe:
+		num = entities.NewBlockNumber(big.NewInt(m.head - 1))
+	case entities.BlockTagFinalized:
+		num = entities.NewBlockNumber(big.NewInt(m.head - 2))
+	}
+
+	block, ok := m.blocks[num]
+	if !ok {
+		return nil, fmt.Errorf("block %s not found", num)
+	}
+
+	return &entities.Block{
+		Number:    block.Number,
+		Timestamp: block.Timestamp,
+	}, nil
+}
+
+func (m *nodeClientMock) BlocksByNumbers(
+	_ context.Context,
+	nums []entities.BlockNumber,
+) ([]*entities.Block, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.batchSupported {
+		return nil, ErrorBatchNotSupported
+	}
+
+	m.batchCalls++
+
+	out := make([]*entities.Block, len(nums))
+
+	for i, num := range nums {
+		block, ok := m.blocks[num]
+		if !ok {
+			return nil, fmt.Errorf("block %s not found", num)
+		}
+
+		out[i] = block
+	}
+
+	return out, nil
+}
+
+func (m *nodeClientMock) BlockReceipts(
+	_ context.Context,
+	num entities.BlockNumber,
+) ([]*entities.Receipt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	block, ok := m.blocks[num]
+	if !ok {
+		return nil, fmt.Errorf("block %s not found", num)
+	}
+
+	receipts := make([]*entities.Receipt, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		receipts[i] = m.receipt(block, tx)
+	}
+
+	return receipts, nil
+}
+
+// TransactionReceipt returns a receipt of a transaction
+func (m *nodeClientMock) TransactionReceipt(_ context.Context, hash entities.Hash) (*entities.Receipt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, block := range m.blocks {
+		for _, tx := range block.Transactions {
+			if tx.Hash == hash {
+				return m.receipt(block, tx), nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("receipt of %s not found", hash)
+}
+
+// receipt returns a receipt of the block transaction. Contracts are created
+// at the contractAddress of the block number
+func (m *nodeClientMock) receipt(block *entities.Block, tx *entities.Transaction) *entities.Receipt {
+	receipt := &entities.Receipt{
+		TransactionHash: tx.Hash,
+		Status:          big.NewInt(1),
+	}
+
+	if m.reverted[tx.Hash] {
+		receipt.Status = new(big.Int)
+	}
+
+	if tx.IsCreation() {
+		contract := contractAddress(block.Number.Int64())
+		receipt.ContractAddress = &contract
+	}
+
+	return receipt
+}
+
+func (m *nodeClientMock) BlockTraces(
+	ctx context.Context,
+	num entities.BlockNumber,
+) ([]*entities.TxTrace, error) {
+	block, err := m.BlockInfoByNumber(ctx, num)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*entities.TxTrace, len(block.Transactions))
+
+	for i, tx := range block.Transactions {
+		out[i] = &entities.TxTrace{
+			TxHash: tx.Hash,
+			Result: &entities.CallFrame{Type: entities.CallTypeCall, From: tx.From, To: *tx.To, Value: tx.Value},
+		}
+	}
+
+	return out, nil
+}
+
+// BalanceAt returns balances of the original chain. A starts with 1000000 wei
+// and sends i+1 wei to B in block i
+func (m *nodeClientMock) BalanceAt(
+	_ context.Context,
+	address entities.Address,
+	num entities.BlockNumber,
+) (*big.Int, error) {
+	n, err := num.ToInt()
+	if err != nil {
+		return nil, err
+	}
+
+	// 1 + 2 + ... + n+1
+	sent := n.Int64() * (n.Int64() + 1) / 2
+	sent += n.Int64() + 1
+
+	switch address {
+	case addr("A"):
+		return big.NewInt(1_000_000 - sent), nil
+	case addr("B"):
+		return big.NewInt(sent), nil
+	default:
+		return new(big.Int), nil
+	}
+}
+
+// CodeAt returns code of created contracts. Code of D is an
+// EIP-7702 delegation. Other addresses are externally owned
+func (m *nodeClientMock) CodeAt(_ context.Context, address entities.Address, _ entities.BlockNumber) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.codeCalls++
+
+	switch {
+	case address[0] == contractPrefix:
+		return "0x6080604052", nil
+	case address == addr("D"):
+		return "0xef0100000000000000000000000000000000000000aa", nil
+	default:
+		return "0x", nil
+	}
+}
+
+// Logs returns a Transfer log of each token per block. Block i contains
+// a transfer of i+1 units of tokenA and an ERC-721 transfer of tokenB.
+// Only logs of even blocks carry the block timestamp
+func (m *nodeClientMock) Logs(
+	_ context.Context,
+	filter entities.LogFilter,
+) ([]*entities.Log, error) {
+	from, err := filter.FromBlock.ToInt()
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := filter.ToBlock.ToInt()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*entities.Log
+
+	for i := from.Int64(); i <= to.Int64(); i++ {
+		logs := []*entities.Log{
+			{
+				Address:     tokenA,
+				Topics:      []entities.Hash{entities.ERC20TransferTopic, topicA, topicB},
+				Data:        fmt.Sprintf("0x%064x", i+1),
+				BlockNumber: big.NewInt(i),
+			},
+			{
+				Address:     tokenB,
+				Topics:      []entities.Hash{entities.ERC20TransferTopic, topicA, topicB, topicA},
+				Data:        "0x",
+				BlockNumber: big.NewInt(i),
+			},
+		}
+
+		for _, l := range logs {
+			if i%2 == 0 {
+				l.BlockTimestamp = big.NewInt(i * 12)
+			}
+
+			if len(filter.Addresses) == 0 || slices.Contains(filter.Addresses, l.Address) {
+				out = append(out, l)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+var (
+	tokenA = entities.Address{19: 0xaa}
+	tokenB = entities.Address{19: 0xbb}
+	topicA = entities.Hash{31: 0xa}
+	topicB = entities.Hash{31: 0xb}
+)
+
+// First byte of the mock created contracts addresses
+const contractPrefix = 0xc
+
+// addr returns a test address ending with the name bytes
+func addr(name string) entities.Address {
+	var a entities.Address
+	copy(a[entities.AddressLength-len(name):], name)
+
+	return a
+}
+
+// to returns a test transaction recipient ending with the name bytes
+func to(name string) *entities.Address {
+	a := addr(name)
+
+	return &a
+}
+
+// hash returns a test hash ending with the name bytes
+func hash(name string) entities.Hash {
+	var h entities.Hash
+	copy(h[entities.HashLength-len(name):], name)
+
+	return h
+}
+
+// contractAddress returns an address of the contract created in block n
+func contractAddress(n int64) entities.Address {
+	a := addr(fmt.Sprint(n))
+	a[0] = contractPrefix
+
+	return a
+}
+
+func TestStreamTransfersBatching(t *testing.T) {
+	for _, batchSupported := range []bool{true, false} {
+		t.Run(fmt.Sprintf("batch supported: %v", batchSupported), func(t *testing.T) {
+			client := newNodeClientMock(30, batchSupported)
+
+			ethInteractor := NewEthInteractor(
+				slog.Default(),
+				client,
+				WithBatchSize(10),
+			)
+
+			report, err := ethInteractor.TopChangedAddresses(
+				context.TODO(),
+				Query{NumBlocks: 25, Limit: 1},
+			)
+			if err != nil {
+				t.Fatalf("error: %s\n", err.Error())
+			}
+
+			if report.Wallets[0].TxCount != 25 {
+				t.Fatalf("invalid tx count: %d | Expected: 25", report.Wallets[0].TxCount)
+			}
+
+			expectedBatchCalls, expectedSingleCalls := 0, 25
+			if batchSupported {
+				expectedBatchCalls, expectedSingleCalls = 3, 0
+			}
+
+			if client.batchCalls != expectedBatchCalls || client.singleCalls != expectedSingleCalls {
+				t.Fatalf(
+					"invalid calls: %d batch, %d single | Expected: %d batch, %d single",
+					client.batchCalls, client.singleCalls, expectedBatchCalls, expectedSingleCalls,
+				)
+			}
+		})
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	// Batches containing unavailable blocks fail partway and are fetched one by one
+	for _, batchSize := range []int{1, 4} {
+		t.Run(fmt.Sprintf("batch size %d", batchSize), func(t *testing.T) {
+			client := newNodeClientMock(30, true)
+
+			// Blocks 27 and 20 are unavailable
+			delete(client.blocks, entities.NewBlockNumber(big.NewInt(27)))
+			delete(client.blocks, entities.NewBlockNumber(big.NewInt(20)))
+
+			ethInteractor := NewEthInteractor(slog.Default(), client, WithBatchSize(batchSize))
+
+			checkCoverage(t, ethInteractor)
+
+			if batchSize > 1 && client.batchCalls == 0 {
+				t.Fatal("blocks must be requested with batches")
+			}
+		})
+	}
+}
+
+// checkCoverage checks a report of the last 10 blocks missing blocks 20 and 27
+func checkCoverage(t *testing.T, ethInteractor EthInteractor) {
+	t.Helper()
+
+	report, err := ethInteractor.TopChangedAddresses(
+		context.TODO(),
+		Query{NumBlocks: 10, Limit: 1},
+	)
+	if err != nil {
+		t.Fatalf("error: %s\n", err.Error())
+	}
+
+	c := report.Coverage
+
+	if c.FromBlock != 20 || c.ToBlock != 29 || c.Requested != 10 || len(c.Processed) != 8 {
+		t.Fatalf(
+			"invalid coverage: [%d, %d], %d requested, %d processed | Expected: [20, 29], 10 requested, 8 processed",
+			c.FromBlock, c.ToBlock, c.Requested, len(c.Processed),
+		)
+	}
+
+	if !slices.Equal(c.Missing, []uint64{20, 27}) {
+		t.Fatalf("invalid missing blocks: %v | Expected: [20 27]", c.Missing)
+	}
+
+	if report.Wallets[0].TxCount != 8 {
+		t.Fatalf("invalid tx count: %d | Expected: 8", report.Wallets[0].TxCount)
+	}
+
+	_, err = ethInteractor.TopChangedAddresses(
+		context.TODO(),
+		Query{NumBlocks: 10, Limit: 1, Strict: true},
+	)
+
+	var missingErr *MissingBlocksError
+	if !errors.As(err, &missingErr) {
+		t.Fatalf("invalid error: %v | Expected: MissingBlocksError", err)
+	}
+
+	if !slices.Equal(missingErr.Missing, []uint64{20, 27}) {
+		t.Fatalf("invalid missing blocks: %v | Expected: [20 27]", missingErr.Missing)
+	}
+}
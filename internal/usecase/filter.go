@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+// AddressKind is a kind of an on-chain address
+type AddressKind string
+
+const (
+	// Externally owned account. Accounts delegated to a contract
+	// with EIP-7702 are externally owned too
+	AddressKindEOA AddressKind = "eoa"
+	// Contract account
+	AddressKindContract AddressKind = "contract"
+)
+
+// ParseAddressKind parses an address kind. An empty string means any kind
+func ParseAddressKind(s string) (AddressKind, error) {
+	switch k := AddressKind(strings.ToLower(s)); k {
+	case "", AddressKindEOA, AddressKindContract:
+		return k, nil
+	default:
+		return "", fmt.Errorf("error unknown address kind %q", s)
+	}
+}
+
+// EIP-7702 delegation designator code prefix
+const delegationPrefix = "0xef0100"
+
+// Maximum amount of addresses in the kinds cache
+const maxKindsCacheSize = 100_000
+
+// addressSet is a set of addresses
+type addressSet map[entities.Address]struct{}
+
+// newAddressSet returns a set of the addresses
+func newAddressSet(addresses []entities.Address) addressSet {
+	set := make(addressSet, len(addresses))
+	for _, a := range addresses {
+		set[a] = struct{}{}
+	}
+
+	return set
+}
+
+// has reports whether the set contains the address
+func (s addressSet) has(address entities.Address) bool {
+	_, ok := s[address]
+
+	return ok
+}
+
+// kindsCache caches kinds of addresses resolved with eth_getCode
+type kindsCache struct {
+	mu    sync.RWMutex
+	kinds map[entities.Address]AddressKind
+}
+
+// newKindsCache returns a new empty kinds cache
+func newKindsCache() *kindsCache {
+	return &kindsCache{kinds: make(map[entities.Address]AddressKind)}
+}
+
+// get returns a cached address kind
+func (c *kindsCache) get(address entities.Address) (AddressKind, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	k, ok := c.kinds[address]
+
+	return k, ok
+}
+
+// set caches the address kind. The cache is reset when full
+func (c *kindsCache) set(address entities.Address, kind AddressKind) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.kinds) >= maxKindsCacheSize {
+		c.kinds = make(map[entities.Address]AddressKind)
+	}
+
+	c.kinds[address] = kind
+}
+
+// addressKind returns the kind of the address at the latest block
+func (t *ethInteractor) addressKind(ctx context.Context, address entities.Address) (AddressKind, error) {
+	if kind, ok := t.kinds.get(address); ok {
+		return kind, nil
+	}
+
+	code, err := t.client.CodeAt(ctx, address, entities.BlockTagLatest)
+	if err != nil {
+		return "", fmt.Errorf("error fetch code of %s. %w", address, err)
+	}
+
+	kind := AddressKindContract
+	if code == "" || code == "0x" || strings.HasPrefix(code, delegationPrefix) {
+		kind = AddressKindEOA
+	}
+
+	t.kinds.set(address, kind)
+
+	return kind, nil
+}
+
+// selectWallets returns up to q.Limit wallets ranked the highest by q.Rank
+// matching the query and the configured address filters
+func (t *ethInteractor) selectWallets(
+	ctx context.Context,
+	wallets entities.Wallets,
+	q Query,
+) (entities.Wallets, error) {
+	var (
+		include    = newAddressSet(q.Include)
+		exclude    = newAddressSet(q.Exclude)
+		categories = make(map[string]struct{}, len(q.ExcludeCategories))
+		out        = make(entities.Wallets, 0, q.Limit)
+	)
+
+	for _, c := range q.ExcludeCategories {
+		categories[strings.ToLower(c)] = struct{}{}
+	}
+
+	for _, w := range wallets.TopBy(q.Rank, 0) {
+		if q.Limit > 0 && len(out) == q.Limit {
+			break
+		}
+
+		if !t.matchesLists(w.Address, include, exclude) {
+			continue
+		}
+
+		if t.labels != nil {
+			w.Label, _ = t.labels.Label(w.Address)
+		}
+
+		if _, ok := categories[labelCategory(w.Label)]; ok {
+			continue
+		}
+
+		if q.Kind != "" {
+			kind, err := t.addressKind(ctx, w.Address)
+			if err != nil {
+				return nil, err
+			}
+
+			if kind != q.Kind {
+				continue
+			}
+		}
+
+		out = append(out, w)
+	}
+
+	return out, nil
+}
+
+// matchesLists reports whether the address passes both the configured
+// and the query include and exclude lists
+func (t *ethInteractor) matchesLists(address entities.Address, include, exclude addressSet) bool {
+	if len(t.include) > 0 && !t.include.has(address) {
+		return false
+	}
+
+	if len(include) > 0 && !include.has(address) {
+		return false
+	}
+
+	return !t.exclude.has(address) && !exclude.has(address)
+}
+
+// labelCategory returns the label category. Unlabeled addresses have no category
+func labelCategory(l *entities.Label) string {
+	if l == nil {
+		return ""
+	}
+
+	return l.Category
+}
@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+func TestSelectWallets(t *testing.T) {
+	for _, tc := range filterTests {
+		t.Run(tc.Title, func(t *testing.T) {
+			client := newNodeClientMock(30, true)
+
+			// Transaction of block 29 deploys a contract
+			client.blocks[entities.NewBlockNumber(big.NewInt(29))].Transactions[0].To = nil
+
+			ethInteractor := NewEthInteractor(
+				slog.Default(),
+				client,
+				WithAddressLists(tc.ConfiguredInclude, tc.ConfiguredExclude),
+				WithLabels(labelsMock{
+					addr("A"):           {Name: "Exchange hot wallet", Category: entities.LabelCategoryExchange},
+					contractAddress(29): {Name: "Bot", Category: entities.LabelCategoryMEVBot},
+				}),
+			)
+
+			// The kinds cache is reused by the second query
+			for i := 0; i < 2; i++ {
+				report, err := ethInteractor.TopChangedAddresses(context.TODO(), tc.Query)
+				if err != nil {
+					t.Fatalf("error: %s\n", err.Error())
+				}
+
+				addresses := make([]entities.Address, len(report.Wallets))
+				for i, w := range report.Wallets {
+					addresses[i] = w.Address
+				}
+
+				if !slices.Equal(addresses, tc.ExpectedResult) {
+					t.Fatalf("invalid result: %v | Expected: %v", addresses, tc.ExpectedResult)
+				}
+
+				if report.Wallets[0].Address == addr("A") && report.Wallets[0].Label == nil {
+					t.Fatalf("wallet A has no label")
+				}
+			}
+
+			if client.codeCalls != tc.ExpectedCodeCalls {
+				t.Fatalf("invalid code calls: %d | Expected: %d", client.codeCalls, tc.ExpectedCodeCalls)
+			}
+		})
+	}
+}
+
+type FilterTestCase struct {
+	Title             string
+	Query             Query
+	ConfiguredInclude []entities.Address
+	ConfiguredExclude []entities.Address
+	ExpectedResult    []entities.Address
+	ExpectedCodeCalls int
+}
+
+// Blocks 28 and 29: A: -59, contract of block 29: +30, B: +29
+var filterTests = []FilterTestCase{
+	{
+		Title:          "No filters",
+		Query:          Query{NumBlocks: 2, Limit: 10},
+		ExpectedResult: []entities.Address{addr("A"), contractAddress(29), addr("B")},
+	},
+	{
+		Title:          "Limited",
+		Query:          Query{NumBlocks: 2, Limit: 2, Exclude: []entities.Address{contractAddress(29)}},
+		ExpectedResult: []entities.Address{addr("A"), addr("B")},
+	},
+	{
+		Title:          "Include list",
+		Query:          Query{NumBlocks: 2, Limit: 10, Include: []entities.Address{addr("B"), contractAddress(29)}},
+		ExpectedResult: []entities.Address{contractAddress(29), addr("B")},
+	},
+	{
+		Title:             "Configured and query lists",
+		Query:             Query{NumBlocks: 2, Limit: 10, Exclude: []entities.Address{addr("B")}},
+		ConfiguredExclude: []entities.Address{addr("A")},
+		ExpectedResult:    []entities.Address{contractAddress(29)},
+	},
+	{
+		Title:             "Configured include list",
+		Query:             Query{NumBlocks: 2, Limit: 10, Include: []entities.Address{addr("A"), addr("B")}},
+		ConfiguredInclude: []entities.Address{addr("B"), contractAddress(29)},
+		ExpectedResult:    []entities.Address{addr("B")},
+	},
+	{
+		Title:             "Contracts only",
+		Query:             Query{NumBlocks: 2, Limit: 10, Kind: AddressKindContract},
+		ExpectedResult:    []entities.Address{contractAddress(29)},
+		ExpectedCodeCalls: 3,
+	},
+	{
+		Title:             "EOA only",
+		Query:             Query{NumBlocks: 2, Limit: 1, Kind: AddressKindEOA},
+		ExpectedResult:    []entities.Address{addr("A")},
+		ExpectedCodeCalls: 1,
+	},
+	{
+		Title:          "Excluded categories",
+		Query:          Query{NumBlocks: 2, Limit: 10, ExcludeCategories: []string{"MEV_BOT", "bridge"}},
+		ExpectedResult: []entities.Address{addr("A"), addr("B")},
+	},
+}
+
+// labelsMock is a LabelRegistry mock
+type labelsMock map[entities.Address]*entities.Label
+
+func (m labelsMock) Label(address entities.Address) (*entities.Label, bool) {
+	l, ok := m[address]
+
+	return l, ok
+}
+
+func TestAddressKind(t *testing.T) {
+	ethInteractor := NewEthInteractor(slog.Default(), newNodeClientMock(1, true)).(*ethInteractor)
+
+	expected := map[entities.Address]AddressKind{
+		addr("A"):           AddressKindEOA,
+		addr("D"):           AddressKindEOA,
+		contractAddress(29): AddressKindContract,
+	}
+
+	for address, e := range expected {
+		kind, err := ethInteractor.addressKind(context.TODO(), address)
+		if err != nil {
+			t.Fatalf("error: %s\n", err.Error())
+		}
+
+		if kind != e {
+			t.Fatalf("invalid kind of %s: %s | Expected: %s", address, kind, e)
+		}
+	}
+}
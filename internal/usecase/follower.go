@@ -0,0 +1,392 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+	"github.com/optclblast/blk/internal/logger"
+)
+
+// headFollower follows the chain head and incrementally maintains
+// balance deltas of the last window blocks. Every block deltas are
+// kept separately, so a block leaving the window is subtracted from
+// the running aggregate.
+type headFollower struct {
+	t        *ethInteractor
+	interval time.Duration
+	window   uint64
+
+	mu sync.RWMutex
+	// Last followed head block number
+	head uint64
+	// map [Block number => Followed block]
+	blocks map[uint64]*followedBlock
+	// Sum of all the blocks deltas
+	aggregate *entities.DeltaSet
+}
+
+// followedBlock is a block within the followed window
+type followedBlock struct {
+	hash       entities.Hash
+	parentHash entities.Hash
+	deltas     *entities.DeltaSet
+}
+
+// newHeadFollower returns a new head follower of the last window blocks
+func newHeadFollower(
+	t *ethInteractor,
+	interval time.Duration,
+	window int,
+) *headFollower {
+	return &headFollower{
+		t:         t,
+		interval:  interval,
+		window:    uint64(window),
+		blocks:    make(map[uint64]*followedBlock, window),
+		aggregate: entities.NewDeltaSet(),
+	}
+}
+
+// run polls the chain head until ctx is done
+func (f *headFollower) run(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := f.poll(ctx); err != nil && ctx.Err() == nil {
+			f.t.log.Error("error follow head", logger.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches the blocks of the current window that are not followed yet.
+// Blocks orphaned by a chain reorganization are rolled back and refetched.
+// Blocks that could not be fetched are retried on the next poll
+func (f *headFollower) poll(ctx context.Context) error {
+	headNumber, err := f.t.client.LastBlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("error fetch last block number. %w", err)
+	}
+
+	n, err := headNumber.ToInt()
+	if err != nil {
+		return fmt.Errorf("error map last block number to numeric. %w", err)
+	}
+
+	head := n.Uint64()
+	from := head + 1 - min(f.window, head+1)
+
+	f.fetchMissing(ctx, from, head)
+
+	if orphaned := f.repair(ctx, from); len(orphaned) > 0 {
+		f.t.observeReorgs(orphaned)
+	}
+
+	f.advance(head)
+
+	return nil
+}
+
+// fetchMissing fetches the [from, head] window blocks that are not followed yet
+func (f *headFollower) fetchMissing(ctx context.Context, from, head uint64) {
+	var missing []entities.BlockNumber
+
+	f.mu.RLock()
+
+	for num := head; num >= from && num <= head; num-- {
+		if _, ok := f.blocks[num]; !ok {
+			missing = append(missing, entities.NewBlockNumber(new(big.Int).SetUint64(num)))
+		}
+	}
+
+	f.mu.RUnlock()
+
+	for i := 0; i < len(missing); i += f.t.batchSize {
+		batch := missing[i:min(i+f.t.batchSize, len(missing))]
+
+		for _, block := range f.t.fetchBlocks(ctx, batch) {
+			f.add(block, from)
+		}
+	}
+}
+
+// repair refetches both sides of every broken parent link and walks down from
+// the child until the parent hash matches, so a reorg of any depth within the
+// window is repaired in a single poll. Links still broken after the last pass
+// are dropped and refetched on the next poll. Returns distinct heights whose
+// blocks were replaced
+func (f *headFollower) repair(ctx context.Context, from uint64) []uint64 {
+	orphaned := make(map[uint64]struct{})
+
+	for pass := 0; pass < maxChainRepairPasses; pass++ {
+		links := f.brokenLinks()
+		if len(links) == 0 {
+			break
+		}
+
+		refetched := make(map[uint64]struct{})
+
+		for _, num := range links {
+			for n := num; n > from && f.isBroken(n); n-- {
+				var heights []uint64
+
+				for _, h := range []uint64{n - 1, n} {
+					if _, ok := refetched[h]; !ok {
+						refetched[h] = struct{}{}
+						heights = append(heights, h)
+					}
+				}
+
+				for _, block := range f.t.refetchBlocks(ctx, heights) {
+					if f.replace(block) {
+						orphaned[block.Number.Uint64()] = struct{}{}
+					}
+				}
+			}
+		}
+	}
+
+	f.dropBrokenLinks()
+
+	heights := make([]uint64, 0, len(orphaned))
+	for num := range orphaned {
+		heights = append(heights, num)
+	}
+
+	return heights
+}
+
+// brokenLinks returns numbers of the followed blocks whose parent hash does
+// not match the hash of the previous block from the newest to the oldest
+func (f *headFollower) brokenLinks() []uint64 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var out []uint64
+
+	for num := range f.blocks {
+		if f.brokenLink(num) {
+			out = append(out, num)
+		}
+	}
+
+	slices.Sort(out)
+	slices.Reverse(out)
+
+	return out
+}
+
+// isBroken reports whether the parent hash of the block does not match the
+// hash of the previous block
+func (f *headFollower) isBroken(num uint64) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.brokenLink(num)
+}
+
+// brokenLink reports whether the parent hash of the block does not match the
+// hash of the previous block. Must be called under the lock
+func (f *headFollower) brokenLink(num uint64) bool {
+	if num == 0 {
+		return false
+	}
+
+	child, parent := f.blocks[num], f.blocks[num-1]
+
+	return child != nil && parent != nil && child.parentHash != parent.hash
+}
+
+// replace replaces the followed block at the same height. Returns true if
+// a block of another hash was replaced
+func (f *headFollower) replace(block *entities.Block) bool {
+	followed := f.newFollowedBlock(block)
+	num := block.Number.Uint64()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	old, ok := f.blocks[num]
+	if ok {
+		if old.hash == followed.hash {
+			return false
+		}
+
+		f.aggregate.Sub(old.deltas)
+	}
+
+	f.blocks[num] = followed
+	f.aggregate.Add(followed.deltas)
+
+	return ok
+}
+
+// dropBrokenLinks subtracts both blocks of every broken parent link,
+// so they are refetched on the next poll
+func (f *headFollower) dropBrokenLinks() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var broken []uint64
+
+	for num := range f.blocks {
+		if f.brokenLink(num) {
+			broken = append(broken, num)
+		}
+	}
+
+	for _, num := range broken {
+		f.t.log.Warn("dropping blocks with broken parent link", slog.Uint64("block number", num))
+
+		for _, h := range []uint64{num - 1, num} {
+			if block, ok := f.blocks[h]; ok {
+				f.aggregate.Sub(block.deltas)
+				delete(f.blocks, h)
+			}
+		}
+	}
+}
+
+// add computes the block deltas and adds them to the aggregate
+// if the block is still in the window
+func (f *headFollower) add(block *entities.Block, from uint64) {
+	followed := f.newFollowedBlock(block)
+	num := block.Number.Uint64()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.blocks[num]; ok || num < from {
+		return
+	}
+
+	f.blocks[num] = followed
+	f.aggregate.Add(followed.deltas)
+}
+
+// newFollowedBlock computes the block deltas
+func (f *headFollower) newFollowedBlock(block *entities.Block) *followedBlock {
+	var (
+		deltas = entities.NewDeltaSet()
+		num    = block.Number.Uint64()
+	)
+
+	for _, tx := range block.Transactions {
+		for _, tr := range transactionTransfers(block, tx, f.t.accounting) {
+			tr.BlockNumber, tr.BlockTime = num, block.Timestamp
+			deltas.Apply(tr)
+		}
+	}
+
+	for _, tr := range withdrawalTransfers(block, f.t.accounting) {
+		tr.BlockNumber, tr.BlockTime = num, block.Timestamp
+		deltas.Apply(tr)
+	}
+
+	return &followedBlock{
+		hash:       block.Hash,
+		parentHash: block.ParentHash,
+		deltas:     deltas,
+	}
+}
+
+// advance moves the window to the new head and subtracts
+// the blocks that left the window from the aggregate
+func (f *headFollower) advance(head uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if head < f.head {
+		return
+	}
+
+	f.head = head
+	from := head + 1 - min(f.window, head+1)
+
+	for num, block := range f.blocks {
+		if num < from {
+			f.aggregate.Sub(block.deltas)
+			delete(f.blocks, num)
+		}
+	}
+
+	f.t.log.Debug(
+		"head followed",
+		slog.Uint64("head", head),
+		slog.Int("blocks", len(f.blocks)),
+		slog.Int("wallets", len(f.aggregate.Wallets)),
+	)
+}
+
+// report returns a report of up to limit wallets ranked the highest by r over
+// the last numBlocks blocks. ok is false if the follower does not cover all the blocks
+func (f *headFollower) report(numBlocks int, r entities.Ranking, limit int) (*entities.DeltaReport, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	n := min(uint64(numBlocks), f.head+1)
+	if n == 0 || n > f.window {
+		return nil, false
+	}
+
+	from := f.head + 1 - n
+	coverage := &entities.Coverage{
+		FromBlock: from,
+		ToBlock:   f.head,
+		Requested: int(n),
+		Processed: make([]uint64, 0, n),
+		Missing:   []uint64{},
+	}
+
+	for num := from; num <= f.head; num++ {
+		if _, ok := f.blocks[num]; !ok {
+			return nil, false
+		}
+
+		coverage.Processed = append(coverage.Processed, num)
+	}
+
+	var report *entities.DeltaReport
+
+	switch {
+	case n == uint64(len(f.blocks)):
+		report = f.aggregate.Report(r, limit)
+	case n > uint64(len(f.blocks))/2:
+		// Subtract the oldest blocks from the aggregate copy
+		set := entities.NewDeltaSet()
+		set.Add(f.aggregate)
+
+		for num := range f.blocks {
+			if num < from {
+				set.Sub(f.blocks[num].deltas)
+			}
+		}
+
+		report = set.Report(r, limit)
+	default:
+		// Sum the newest blocks
+		set := entities.NewDeltaSet()
+
+		for num := from; num <= f.head; num++ {
+			set.Add(f.blocks[num].deltas)
+		}
+
+		report = set.Report(r, limit)
+	}
+
+	report.Coverage = coverage
+
+	return report, true
+}
@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+func TestHeadFollower(t *testing.T) {
+	client := newNodeClientMock(30, true)
+
+	followed := NewEthInteractor(
+		slog.Default(),
+		client,
+		WithMaxWindow(10),
+		WithHeadFollower(time.Hour),
+	).(*ethInteractor)
+
+	fresh := NewEthInteractor(slog.Default(), client, WithMaxWindow(10))
+
+	for _, extend := range []int64{0, 3, 25} {
+		client.extend(extend)
+
+		if err := followed.follower.poll(context.TODO()); err != nil {
+			t.Fatalf("error: %s\n", err.Error())
+		}
+
+		if len(followed.follower.blocks) != 10 {
+			t.Fatalf("invalid followed blocks: %d | Expected: 10", len(followed.follower.blocks))
+		}
+
+		for _, numBlocks := range []int{1, 4, 7, 10} {
+			t.Run(fmt.Sprintf("head %d, %d blocks", client.head, numBlocks), func(t *testing.T) {
+				q := Query{NumBlocks: numBlocks, Limit: 2}
+
+				report, ok := followed.follower.report(q.NumBlocks, entities.RankingAbs, q.Limit)
+				if !ok {
+					t.Fatalf("window is not covered by the follower")
+				}
+
+				expected, err := fresh.TopChangedAddresses(context.TODO(), q)
+				if err != nil {
+					t.Fatalf("error: %s\n", err.Error())
+				}
+
+				if report.Coverage.FromBlock != expected.Coverage.FromBlock ||
+					report.Coverage.ToBlock != expected.Coverage.ToBlock {
+					t.Fatalf(
+						"invalid window: [%d, %d] | Expected: [%d, %d]",
+						report.Coverage.FromBlock, report.Coverage.ToBlock,
+						expected.Coverage.FromBlock, expected.Coverage.ToBlock,
+					)
+				}
+
+				// Wallets order is deterministic, so the reports are equal
+				if len(report.Wallets) != len(expected.Wallets) {
+					t.Fatalf("invalid wallets: %d | Expected: %d", len(report.Wallets), len(expected.Wallets))
+				}
+
+				for i, w := range report.Wallets {
+					e := expected.Wallets[i]
+
+					if w.Address != e.Address || w.Delta.Cmp(e.Delta) != 0 || w.TxCount != e.TxCount {
+						t.Fatalf(
+							"invalid wallet #%d: %s %s %d | Expected: %s %s %d",
+							i, w.Address, w.Delta, w.TxCount, e.Address, e.Delta, e.TxCount,
+						)
+					}
+
+					// Deltas of the blocks left the window are dropped
+					if len(w.BlockDeltas) != len(e.BlockDeltas) {
+						t.Fatalf(
+							"invalid block deltas of %s: %d | Expected: %d",
+							w.Address, len(w.BlockDeltas), len(e.BlockDeltas),
+						)
+					}
+				}
+			})
+		}
+	}
+
+	if _, ok := followed.follower.report(11, entities.RankingAbs, 1); ok {
+		t.Fatalf("window larger than the followed one must not be covered")
+	}
+}
@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"context"
+	"math/big"
+	"time"
 
 	"github.com/optclblast/blk/internal/entities"
 )
@@ -13,7 +15,60 @@ type NodeClient interface {
 
 	// BlockInfoByNumber accepts block number and returns all information, including
 	// transactions, related to that block.
-	// BlockInfoByNumber may return ErrorRateLimitExceeded and you may want to wrap it into
-	// backoff
+	// Transient node provider errors, e.g. rate limits, are expected to be retried
+	// by the NodeClient implementation
 	BlockInfoByNumber(ctx context.Context, num entities.BlockNumber) (*entities.Block, error)
+
+	// BlockHeaderByNumber accepts block number or tag and returns the block
+	// info without transactions details. Only transactions hashes are filled.
+	BlockHeaderByNumber(ctx context.Context, num entities.BlockNumber) (*entities.Block, error)
+
+	// BlocksByNumbers accepts block numbers and returns blocks info in the same
+	// order using a single batch request. BlocksByNumbers fails if any of the blocks
+	// can not be fetched and returns ErrorBatchNotSupported if the node provider
+	// does not support batch requests.
+	BlocksByNumbers(ctx context.Context, nums []entities.BlockNumber) ([]*entities.Block, error)
+
+	// BlockReceipts accepts block number and returns receipts of all the
+	// transactions included into that block.
+	BlockReceipts(ctx context.Context, num entities.BlockNumber) ([]*entities.Receipt, error)
+
+	// TransactionReceipt accepts transaction hash and returns its receipt.
+	TransactionReceipt(ctx context.Context, hash entities.Hash) (*entities.Receipt, error)
+
+	// BlockTraces accepts block number and returns call traces of all the
+	// transactions included into that block in the same order.
+	BlockTraces(ctx context.Context, num entities.BlockNumber) ([]*entities.TxTrace, error)
+
+	// BalanceAt accepts an address and block number and returns the address
+	// balance in wei at that block. Old blocks require an archive node.
+	BalanceAt(ctx context.Context, address entities.Address, num entities.BlockNumber) (*big.Int, error)
+
+	// CodeAt accepts an address and block number and returns the hex encoded
+	// code deployed at the address. Code of an externally owned account is "0x".
+	CodeAt(ctx context.Context, address entities.Address, num entities.BlockNumber) (string, error)
+
+	// Logs returns event logs matching the filter.
+	Logs(ctx context.Context, filter entities.LogFilter) ([]*entities.Log, error)
+}
+
+// BlockInvalidator is implemented by node clients keeping blocks state,
+// e.g. caches. Blocks at the invalidated heights are dropped, so the next
+// calls fetch them from the node provider.
+type BlockInvalidator interface {
+	// InvalidateBlocks drops blocks at the specified heights
+	InvalidateBlocks(ctx context.Context, nums []uint64) error
+}
+
+// LabelRegistry maps addresses to their human readable labels
+type LabelRegistry interface {
+	// Label returns the address label. ok is false if the address is not labeled
+	Label(address entities.Address) (label *entities.Label, ok bool)
+}
+
+// PriceSource provides historical USD prices of assets
+type PriceSource interface {
+	// PriceAt returns the asset price at the moment, i.e. the latest
+	// price observed not after it
+	PriceAt(ctx context.Context, asset entities.Asset, at time.Time) (*entities.Price, error)
 }
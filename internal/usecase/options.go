@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+// Option configures EthInteractor
+type Option func(t *ethInteractor)
+
+// WithAccounting sets a specific accounting mode. By default only
+// transactions value is taken into account
+func WithAccounting(accounting Accounting) Option {
+	return func(t *ethInteractor) {
+		t.accounting = accounting
+	}
+}
+
+// WithBatchSize sets a specific amount of blocks fetched with a single
+// batch request. Batch size of 1 disables batch requests
+func WithBatchSize(size int) Option {
+	return func(t *ethInteractor) {
+		if size > 0 {
+			t.batchSize = size
+		}
+	}
+}
+
+// WithMaxWindow sets a specific maximum amount of blocks in a query window
+func WithMaxWindow(blocks int) Option {
+	return func(t *ethInteractor) {
+		if blocks > 0 {
+			t.maxWindow = blocks
+		}
+	}
+}
+
+// WithAddressLists sets addresses every result is limited to and addresses
+// every result excludes. An empty include list means any address
+func WithAddressLists(include, exclude []entities.Address) Option {
+	return func(t *ethInteractor) {
+		t.include = newAddressSet(include)
+		t.exclude = newAddressSet(exclude)
+	}
+}
+
+// WithLabels sets a registry of address labels attached to the result wallets
+func WithLabels(labels LabelRegistry) Option {
+	return func(t *ethInteractor) {
+		t.labels = labels
+	}
+}
+
+// WithPrices sets a source of USD prices the result wallets deltas are valued with
+func WithPrices(prices PriceSource) Option {
+	return func(t *ethInteractor) {
+		t.prices = prices
+	}
+}
+
+// WithHeadFollower enables a background head follower polling the chain head
+// every interval. Head relative queries are answered from memory when the
+// follower covers their window
+func WithHeadFollower(interval time.Duration) Option {
+	return func(t *ethInteractor) {
+		t.followInterval = interval
+	}
+}
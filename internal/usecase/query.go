@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+// Query is a most changed addresses query
+type Query struct {
+	// Amount of blocks to process. The window ends at the HEAD block unless
+	// an upper bound is set
+	NumBlocks int
+	// Explicit window bounds. A block number in decimal or hex, or one of
+	// the tags: latest, safe, finalized, earliest. Empty if not set
+	FromBlock string
+	ToBlock   string
+	// Time window bounds resolved to the first block produced at or after
+	// FromTime and the last block produced at or before ToTime. Zero if not set
+	FromTime time.Time
+	ToTime   time.Time
+	// Maximum amount of wallets in the result
+	Limit int
+	// Rank orders the result wallets. Empty means entities.RankingAbs
+	Rank entities.Ranking
+	// Strict fails the query with MissingBlocksError if any
+	// block of the window could not be processed
+	Strict bool
+	// Include limits the result to the addresses. Empty means any
+	Include []entities.Address
+	// Exclude removes the addresses from the result
+	Exclude []entities.Address
+	// ExcludeCategories removes the addresses labeled with the categories from the result
+	ExcludeCategories []string
+	// Kind limits the result to the addresses of the kind. Empty means any
+	Kind AddressKind
+	// Verify compares the resulting wallets deltas with their on-chain
+	// balance changes. Native ETH only
+	Verify bool
+	// Token selects ERC-20 transfers of a token contract address.
+	// Zero means native ETH
+	Token entities.Address
+	// AllTokens selects ERC-20 transfers of all the tokens. Token is ignored
+	AllTokens bool
+}
+
+// filtered reports whether the query filters the result addresses
+func (q Query) filtered() bool {
+	return len(q.Include) > 0 || len(q.Exclude) > 0 || len(q.ExcludeCategories) > 0 || q.Kind != ""
+}
+
+// tokens reports whether the query selects ERC-20 transfers
+func (q Query) tokens() bool {
+	return q.AllTokens || !q.Token.IsZero()
+}
+
+// headRelative reports whether the query window ends at the HEAD block
+// and is defined by the amount of blocks only
+func (q Query) headRelative() bool {
+	return q.FromBlock == "" &&
+		q.FromTime.IsZero() &&
+		q.ToTime.IsZero() &&
+		(q.ToBlock == "" || q.ToBlock == string(entities.BlockTagLatest))
+}
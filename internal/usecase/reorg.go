@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"context"
+	"expvar"
+	"log/slog"
+	"math/big"
+	"slices"
+	"strconv"
+
+	"github.com/optclblast/blk/internal/entities"
+	"github.com/optclblast/blk/internal/logger"
+)
+
+// Maximum amount of passes over a blocks window repairing broken links
+const maxChainRepairPasses = 3
+
+// Observed chain reorganizations by depth
+var reorgsByDepth = expvar.NewMap("reorgs_by_depth")
+
+// verifyChain checks the parent hash continuity of the [from, to] window blocks,
+// so blocks of different forks are not mixed. Both heights of a broken link are
+// refetched. Blocks whose links remain broken are dropped from the window
+func (t *ethInteractor) verifyChain(
+	ctx context.Context,
+	blocks map[uint64]*entities.Block,
+	from, to uint64,
+) {
+	orphaned := make(map[uint64]struct{})
+
+	for pass := 0; pass < maxChainRepairPasses; pass++ {
+		if !t.repairChain(ctx, blocks, from, to, orphaned) {
+			break
+		}
+	}
+
+	for _, num := range brokenLinks(blocks, from, to) {
+		t.log.Warn("dropping blocks with broken parent link", slog.Uint64("block number", num))
+
+		delete(blocks, num)
+		delete(blocks, num-1)
+	}
+
+	if len(orphaned) > 0 {
+		heights := make([]uint64, 0, len(orphaned))
+		for num := range orphaned {
+			heights = append(heights, num)
+		}
+
+		t.observeReorgs(heights)
+	}
+}
+
+// repairChain makes a pass over the window from the newest block to the oldest one
+// and refetches blocks of broken links. Heights whose blocks were replaced are
+// added to orphaned. Returns false if no broken links were found
+func (t *ethInteractor) repairChain(
+	ctx context.Context,
+	blocks map[uint64]*entities.Block,
+	from, to uint64,
+	orphaned map[uint64]struct{},
+) bool {
+	var (
+		broken    bool
+		refetched = make(map[uint64]struct{})
+	)
+
+	for num := to; num > from; num-- {
+		child, parent := blocks[num], blocks[num-1]
+		if child == nil || parent == nil || child.ParentHash == parent.Hash {
+			continue
+		}
+
+		broken = true
+
+		var heights []uint64
+
+		for _, h := range []uint64{num - 1, num} {
+			if _, ok := refetched[h]; !ok {
+				refetched[h] = struct{}{}
+				heights = append(heights, h)
+			}
+		}
+
+		for _, block := range t.refetchBlocks(ctx, heights) {
+			h := block.Number.Uint64()
+
+			if blocks[h].Hash != block.Hash {
+				orphaned[h] = struct{}{}
+			}
+
+			blocks[h] = block
+		}
+	}
+
+	return broken
+}
+
+// refetchBlocks invalidates blocks kept by the node client and fetches them again
+func (t *ethInteractor) refetchBlocks(ctx context.Context, heights []uint64) []*entities.Block {
+	if len(heights) == 0 {
+		return nil
+	}
+
+	if invalidator, ok := t.client.(BlockInvalidator); ok {
+		if err := invalidator.InvalidateBlocks(ctx, heights); err != nil {
+			t.log.Error("error invalidate blocks", logger.Err(err), slog.Any("block numbers", heights))
+		}
+	}
+
+	nums := make([]entities.BlockNumber, len(heights))
+	for i, h := range heights {
+		nums[i] = entities.NewBlockNumber(new(big.Int).SetUint64(h))
+	}
+
+	return t.fetchBlocks(ctx, nums)
+}
+
+// observeReorgs reports chain reorganizations that orphaned blocks at the heights.
+// Every run of contiguous heights is a single reorganization
+func (t *ethInteractor) observeReorgs(heights []uint64) {
+	slices.Sort(heights)
+
+	for start, i := 0, 1; i <= len(heights); i++ {
+		if i < len(heights) && heights[i] == heights[i-1]+1 {
+			continue
+		}
+
+		depth := i - start
+
+		reorgsByDepth.Add(strconv.Itoa(depth), 1)
+
+		t.log.Warn(
+			"chain reorganization detected",
+			slog.Int("depth", depth),
+			slog.Uint64("from block", heights[start]),
+			slog.Uint64("to block", heights[i-1]),
+		)
+
+		start = i
+	}
+}
+
+// brokenLinks returns numbers of the window blocks whose parent hash does not
+// match the hash of the previous block
+func brokenLinks(blocks map[uint64]*entities.Block, from, to uint64) []uint64 {
+	var out []uint64
+
+	for num := to; num > from; num-- {
+		child, parent := blocks[num], blocks[num-1]
+		if child != nil && parent != nil && child.ParentHash != parent.Hash {
+			out = append(out, num)
+		}
+	}
+
+	return out
+}
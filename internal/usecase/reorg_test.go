@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"testing"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+func TestVerifyChain(t *testing.T) {
+	client := newNodeClientMock(30, true)
+	ethInteractor := NewEthInteractor(slog.Default(), client).(*ethInteractor)
+
+	// Blocks [20, 29] of the original fork
+	blocks := make(map[uint64]*entities.Block, 10)
+	for i := int64(20); i < 30; i++ {
+		blocks[uint64(i)] = client.blocks[entities.NewBlockNumber(big.NewInt(i))]
+	}
+
+	// Blocks [25, 29] are replaced, but only the head is fetched from the new fork
+	client.reorg(5)
+	blocks[29] = client.blocks[entities.NewBlockNumber(big.NewInt(29))]
+
+	before := depthCount("4")
+
+	ethInteractor.verifyChain(context.TODO(), blocks, 20, 29)
+
+	if len(blocks) != 10 {
+		t.Fatalf("invalid blocks: %d | Expected: 10", len(blocks))
+	}
+
+	if broken := brokenLinks(blocks, 20, 29); len(broken) != 0 {
+		t.Fatalf("invalid broken links: %v | Expected: none", broken)
+	}
+
+	for num := uint64(25); num < 30; num++ {
+		if expected := client.blocks[entities.NewBlockNumber(new(big.Int).SetUint64(num))]; blocks[num] != expected {
+			t.Fatalf("invalid block %d: %s | Expected: %s", num, blocks[num].Hash, expected.Hash)
+		}
+	}
+
+	if after := depthCount("4"); after != before+1 {
+		t.Fatalf("invalid reorgs of depth 4: %d | Expected: %d", after, before+1)
+	}
+}
+
+func TestVerifyChainSeparateReorgs(t *testing.T) {
+	client := newNodeClientMock(30, true)
+	ethInteractor := NewEthInteractor(slog.Default(), client).(*ethInteractor)
+
+	blocks := make(map[uint64]*entities.Block, 10)
+	for i := int64(20); i < 30; i++ {
+		blocks[uint64(i)] = client.blocks[entities.NewBlockNumber(big.NewInt(i))]
+	}
+
+	// Blocks 22 and 26 were fetched from two short-lived forks
+	for _, num := range []uint64{22, 26} {
+		stale := *blocks[num]
+		stale.Hash = hash(fmt.Sprintf("%du", num))
+		blocks[num] = &stale
+	}
+
+	before1, before2 := depthCount("1"), depthCount("2")
+
+	ethInteractor.verifyChain(context.TODO(), blocks, 20, 29)
+
+	if broken := brokenLinks(blocks, 20, 29); len(broken) != 0 {
+		t.Fatalf("invalid broken links: %v | Expected: none", broken)
+	}
+
+	// Two reorgs of depth 1, not a single reorg of depth 2
+	if after := depthCount("1"); after != before1+2 {
+		t.Fatalf("invalid reorgs of depth 1: %d | Expected: %d", after, before1+2)
+	}
+
+	if after := depthCount("2"); after != before2 {
+		t.Fatalf("invalid reorgs of depth 2: %d | Expected: %d", after, before2)
+	}
+}
+
+// depthCount returns an amount of observed reorgs of the depth
+func depthCount(depth string) int64 {
+	if v, ok := reorgsByDepth.Get(depth).(*expvar.Int); ok {
+		return v.Value()
+	}
+
+	return 0
+}
+
+func TestHeadFollowerReorg(t *testing.T) {
+	client := newNodeClientMock(30, true)
+
+	followed := NewEthInteractor(slog.Default(), client, WithMaxWindow(10)).(*ethInteractor)
+	follower := newHeadFollower(followed, 0, 10)
+
+	if err := follower.poll(context.TODO()); err != nil {
+		t.Fatalf("error: %s\n", err.Error())
+	}
+
+	client.reorg(4)
+	client.extend(1)
+
+	if err := follower.poll(context.TODO()); err != nil {
+		t.Fatalf("error: %s\n", err.Error())
+	}
+
+	report, ok := follower.report(10, entities.RankingAbs, 4)
+	if !ok {
+		t.Fatalf("window is not covered by the follower")
+	}
+
+	expected, err := followed.TopChangedAddresses(context.TODO(), Query{NumBlocks: 10, Limit: 4})
+	if err != nil {
+		t.Fatalf("error: %s\n", err.Error())
+	}
+
+	if len(report.Wallets) != len(expected.Wallets) {
+		t.Fatalf("invalid wallets: %d | Expected: %d", len(report.Wallets), len(expected.Wallets))
+	}
+
+	expectedDeltas := make(map[entities.Address]*big.Int, len(expected.Wallets))
+	for _, w := range expected.Wallets {
+		expectedDeltas[w.Address] = w.Delta
+	}
+
+	for _, w := range report.Wallets {
+		if e, ok := expectedDeltas[w.Address]; !ok || w.Delta.Cmp(e) != 0 {
+			t.Fatalf("invalid wallet: %s %s | Expected: %s", w.Address, w.Delta, e)
+		}
+	}
+}
+
+func TestHeadFollowerStaleChild(t *testing.T) {
+	client := newNodeClientMock(30, true)
+
+	followed := NewEthInteractor(slog.Default(), client, WithMaxWindow(10)).(*ethInteractor)
+	follower := newHeadFollower(followed, 0, 10)
+
+	if err := follower.poll(context.TODO()); err != nil {
+		t.Fatalf("error: %s\n", err.Error())
+	}
+
+	// Blocks [28, 29] are replaced, but only block 28 of the new fork is followed,
+	// so the followed block 29 is a stale child of the new block 28
+	client.reorg(2)
+
+	follower.replace(client.blocks[entities.NewBlockNumber(big.NewInt(28))])
+
+	var (
+		depth1   = depthCount("1")
+		depth2   = depthCount("2")
+		inflated = depthCount("11")
+	)
+
+	if err := follower.poll(context.TODO()); err != nil {
+		t.Fatalf("error: %s\n", err.Error())
+	}
+
+	// Only the stale child is orphaned
+	if depthCount("1") != depth1+1 || depthCount("2") != depth2 || depthCount("11") != inflated {
+		t.Fatalf(
+			"invalid reorgs by depth: 1: %d, 2: %d, 11: %d | Expected: 1: %d, 2: %d, 11: %d",
+			depthCount("1"), depthCount("2"), depthCount("11"), depth1+1, depth2, inflated,
+		)
+	}
+
+	for num := uint64(20); num < 30; num++ {
+		block, ok := follower.blocks[num]
+		if !ok {
+			t.Fatalf("block %d is not followed", num)
+		}
+
+		expected := client.blocks[entities.NewBlockNumber(new(big.Int).SetUint64(num))]
+		if block.hash != expected.Hash {
+			t.Fatalf("invalid block %d: %s | Expected: %s", num, block.hash, expected.Hash)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"math/big"
+	"sync"
+
+	"github.com/alitto/pond"
+	"github.com/optclblast/blk/internal/entities"
+	"github.com/optclblast/blk/internal/logger"
+)
+
+// streamTokenTransfers fetches ERC-20 Transfer logs of the [from, to] window
+// and dispatches decoded transfers into a dedicated channel for other workers
+// to process. tokens are token contract addresses. Empty tokens means all
+// the tokens. Logs are fetched in chunks of batchSize blocks. Blocks of
+// fetched chunks are marked in tracker. The transfers channel will be
+// closed internally.
+func (t *ethInteractor) streamTokenTransfers(
+	ctx context.Context,
+	from, to uint64,
+	tokens []entities.Address,
+	tracker *coverageTracker,
+	transfersChan chan<- *entities.Transfer,
+) {
+	numBlocks := int(to - from + 1)
+	fetchPool := pond.New(fetchWorkersPoolSize, numBlocks)
+
+	filter := entities.LogFilter{
+		Addresses: tokens,
+		Topics:    [][]entities.Hash{{entities.ERC20TransferTopic}},
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < numBlocks; i += t.batchSize {
+		chunkTo := to - uint64(i)
+		chunkFrom := chunkTo + 1 - uint64(min(t.batchSize, numBlocks-i))
+
+		chunkFilter := filter
+		chunkFilter.FromBlock = entities.NewBlockNumber(new(big.Int).SetUint64(chunkFrom))
+		chunkFilter.ToBlock = entities.NewBlockNumber(new(big.Int).SetUint64(chunkTo))
+
+		wg.Add(1)
+		fetchPool.Submit(func() {
+			defer wg.Done()
+
+			logs, err := t.client.Logs(ctx, chunkFilter)
+			if err != nil {
+				t.log.Error(
+					"error fetch token transfer logs",
+					logger.Err(err),
+					slog.Uint64("from block", chunkFrom),
+					slog.Uint64("to block", chunkTo),
+				)
+
+				return
+			}
+
+			for num := chunkFrom; num <= chunkTo; num++ {
+				tracker.add(num)
+			}
+
+			for _, l := range logs {
+				if l.Removed {
+					continue
+				}
+
+				if tr, ok := l.ERC20Transfer(); ok {
+					transfersChan <- tr
+				}
+			}
+		})
+	}
+
+	go func() {
+		wg.Wait()
+		close(transfersChan)
+	}()
+}
+
+// walletKey is a key of the wallet deltas of an address in a token.
+// Zero token means native ETH
+type walletKey struct {
+	address entities.Address
+	token   entities.Address
+}
+
+// walletKeyShard returns a concurrent map shard hash of the key.
+// Address bytes are uniformly distributed, so the trailing bytes are used as is
+func walletKeyShard(k walletKey) uint32 {
+	return binary.BigEndian.Uint32(k.address[16:]) ^ binary.BigEndian.Uint32(k.token[16:])
+}
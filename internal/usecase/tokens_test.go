@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"testing"
+)
+
+func TestTokenTransfers(t *testing.T) {
+	client := newNodeClientMock(30, true)
+	ethInteractor := NewEthInteractor(slog.Default(), client, WithBatchSize(4))
+
+	for _, q := range []Query{{Token: tokenA}, {AllTokens: true}} {
+		q.NumBlocks, q.Limit, q.Strict = 10, 10, true
+
+		t.Run(fmt.Sprintf("all tokens: %v", q.AllTokens), func(t *testing.T) {
+			report, err := ethInteractor.TopChangedAddresses(context.TODO(), q)
+			if err != nil {
+				t.Fatalf("error: %s\n", err.Error())
+			}
+
+			// ERC-721 transfers of tokenB are skipped
+			if len(report.Wallets) != 2 {
+				t.Fatalf("invalid wallets: %d | Expected: 2", len(report.Wallets))
+			}
+
+			// Blocks [20, 29] transfer 21 + 22 + ... + 30 units
+			expected := big.NewInt(255)
+
+			for _, w := range report.Wallets {
+				if w.Token != tokenA {
+					t.Fatalf("invalid token: %s | Expected: %s", w.Token, tokenA)
+				}
+
+				if w.AbsDelta().Cmp(expected) != 0 || w.TxCount != 10 {
+					t.Fatalf(
+						"invalid wallet %s: %s, %d txs | Expected: %s, 10 txs",
+						w.Address, w.AbsDelta(), w.TxCount, expected,
+					)
+				}
+			}
+		})
+	}
+}
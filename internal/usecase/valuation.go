@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/alitto/pond"
+	"github.com/optclblast/blk/internal/entities"
+	"github.com/optclblast/blk/internal/logger"
+)
+
+// valueWallets attaches USD values of the wallets deltas. Every contributing
+// block delta is priced at the block timestamp. Wallets are left without a
+// value if no price source is configured
+func (t *ethInteractor) valueWallets(ctx context.Context, wallets entities.Wallets) {
+	if t.prices == nil || len(wallets) == 0 {
+		return
+	}
+
+	var (
+		times  = t.blockTimes(ctx, wallets)
+		prices = newPriceMemo(t.prices)
+		pool   = pond.New(fetchWorkersPoolSize, len(wallets))
+	)
+
+	for _, w := range wallets {
+		pool.Submit(func() {
+			w.Valuation = t.valueWallet(ctx, w, times, prices)
+		})
+	}
+
+	pool.StopAndWait()
+}
+
+// valueWallet sums the wallet block deltas priced at the blocks timestamps
+func (t *ethInteractor) valueWallet(
+	ctx context.Context,
+	w *entities.Wallet,
+	times map[uint64]time.Time,
+	prices *priceMemo,
+) *entities.Valuation {
+	var (
+		v     = new(entities.Valuation)
+		asset = t.asset(w.Token)
+		sum   = new(big.Rat)
+	)
+
+	for num, delta := range w.BlockDeltas {
+		at, ok := times[num]
+		if !ok {
+			v.Error = fmt.Sprintf("error unknown timestamp of block %d", num)
+
+			return v
+		}
+
+		price, err := prices.priceAt(ctx, asset, num, at)
+		if err != nil {
+			v.Error = fmt.Sprintf("error fetch price at block %d. %s", num, err)
+
+			return v
+		}
+
+		sum.Add(sum, price.Value(delta))
+	}
+
+	v.DeltaUSD = sum
+
+	return v
+}
+
+// asset returns the priced asset of the token. Zero token is native ETH.
+// Token symbols are taken from the token labels symbols
+func (t *ethInteractor) asset(token entities.Address) entities.Asset {
+	if token.IsZero() {
+		return entities.Asset{Symbol: entities.SymbolETH}
+	}
+
+	asset := entities.Asset{Token: token}
+
+	if t.labels != nil {
+		if label, ok := t.labels.Label(token); ok {
+			asset.Symbol = label.Symbol
+		}
+	}
+
+	return asset
+}
+
+// blockTimes returns timestamps of the blocks the wallets deltas were
+// collected in. Timestamps are carried by the transfers of fetched blocks,
+// so only the blocks of logs without timestamps are fetched. Blocks whose
+// headers could not be fetched are omitted
+func (t *ethInteractor) blockTimes(ctx context.Context, wallets entities.Wallets) map[uint64]time.Time {
+	var (
+		mu      sync.Mutex
+		times   = make(map[uint64]time.Time)
+		missing = make(map[uint64]struct{})
+	)
+
+	for _, w := range wallets {
+		for num, at := range w.BlockTimes {
+			times[num] = at
+		}
+	}
+
+	for _, w := range wallets {
+		for num := range w.BlockDeltas {
+			if _, ok := times[num]; !ok {
+				missing[num] = struct{}{}
+			}
+		}
+	}
+
+	if len(missing) == 0 {
+		return times
+	}
+
+	pool := pond.New(fetchWorkersPoolSize, len(missing))
+
+	for num := range missing {
+		pool.Submit(func() {
+			block, err := t.client.BlockHeaderByNumber(ctx, entities.NewBlockNumber(new(big.Int).SetUint64(num)))
+			if err != nil {
+				t.log.Warn("error fetch block header", logger.Err(err), slog.Uint64("block number", num))
+
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			times[num] = block.Timestamp
+		})
+	}
+
+	pool.StopAndWait()
+
+	return times
+}
+
+// priceKey identifies an asset price at a block
+type priceKey struct {
+	token entities.Address
+	block uint64
+}
+
+// priceMemo memoizes prices of a single request, so every asset is priced
+// once per block. priceMemo is safe for concurrent use
+type priceMemo struct {
+	source PriceSource
+
+	mu     sync.Mutex
+	prices map[priceKey]*entities.Price
+}
+
+// newPriceMemo returns a new empty priceMemo
+func newPriceMemo(source PriceSource) *priceMemo {
+	return &priceMemo{
+		source: source,
+		prices: make(map[priceKey]*entities.Price),
+	}
+}
+
+// priceAt returns the asset price at the block timestamp
+func (m *priceMemo) priceAt(
+	ctx context.Context,
+	asset entities.Asset,
+	block uint64,
+	at time.Time,
+) (*entities.Price, error) {
+	key := priceKey{asset.Token, block}
+
+	m.mu.Lock()
+	price, ok := m.prices[key]
+	m.mu.Unlock()
+
+	if ok {
+		return price, nil
+	}
+
+	price, err := m.source.PriceAt(ctx, asset, at)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.prices[key] = price
+	m.mu.Unlock()
+
+	return price, nil
+}
@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+func TestValueWallets(t *testing.T) {
+	for _, tc := range valuationTests {
+		t.Run(tc.Title, func(t *testing.T) {
+			usdt := &entities.Label{Name: "Tether USD", Category: entities.LabelCategoryToken, Symbol: "USDT"}
+
+			opts := []Option{WithLabels(labelsMock{tokenA: usdt})}
+			if tc.Prices != nil {
+				opts = append(opts, WithPrices(tc.Prices))
+			}
+
+			client := newNodeClientMock(30, true)
+			ethInteractor := NewEthInteractor(slog.Default(), client, opts...)
+
+			report, err := ethInteractor.TopChangedAddresses(context.TODO(), tc.Query)
+			if err != nil {
+				t.Fatalf("error: %s\n", err.Error())
+			}
+
+			if client.headerCalls != tc.ExpectedHeaderCalls {
+				t.Fatalf("invalid header calls: %d | Expected: %d", client.headerCalls, tc.ExpectedHeaderCalls)
+			}
+
+			if len(report.Wallets) != len(tc.ExpectedUSD) {
+				t.Fatalf("invalid wallets: %d | Expected: %d", len(report.Wallets), len(tc.ExpectedUSD))
+			}
+
+			for _, w := range report.Wallets {
+				v := w.Valuation
+
+				switch expected := tc.ExpectedUSD[w.Address]; {
+				case tc.Prices == nil:
+					if v != nil {
+						t.Fatalf("wallet %s must not be valued: %+v", w.Address, v)
+					}
+				case expected == "":
+					if v == nil || v.Error == "" || v.DeltaUSD != nil {
+						t.Fatalf("wallet %s valuation must fail: %+v", w.Address, v)
+					}
+				default:
+					if v == nil || v.Error != "" || v.DeltaUSD.RatString() != expected {
+						t.Fatalf("invalid valuation of %s: %+v | Expected: %s", w.Address, v, expected)
+					}
+				}
+			}
+		})
+	}
+}
+
+type ValuationTestCase struct {
+	Title  string
+	Query  Query
+	Prices PriceSource
+	// Expected USD values as fractions. Empty if the valuation fails
+	ExpectedUSD map[entities.Address]string
+	// Expected amount of block headers fetched for their timestamps
+	ExpectedHeaderCalls int
+}
+
+var valuationTests = []ValuationTestCase{
+	{
+		// Block i transfers i+1 wei valued at i dollars per wei,
+		// so blocks [20, 29] sum up to 21*20 + 22*21 + ... + 30*29
+		Title:       "ETH priced at every block",
+		Query:       Query{NumBlocks: 10, Limit: 2},
+		Prices:      pricesMock{},
+		ExpectedUSD: map[entities.Address]string{addr("A"): "-6330", addr("B"): "6330"},
+	},
+	{
+		// Blocks [20, 29] transfer 255 units of a 6 decimals token priced at one dollar.
+		// Only the odd blocks logs have no timestamps
+		Title:               "Token priced through its symbol",
+		Query:               Query{NumBlocks: 10, Limit: 2, Token: tokenA},
+		Prices:              pricesMock{},
+		ExpectedUSD:         map[entities.Address]string{{19: 0xa}: "-51/200000", {19: 0xb}: "51/200000"},
+		ExpectedHeaderCalls: 5,
+	},
+	{
+		Title:       "Missing prices",
+		Query:       Query{NumBlocks: 10, Limit: 2},
+		Prices:      pricesMock{since: time.Unix(25*12, 0)},
+		ExpectedUSD: map[entities.Address]string{addr("A"): "", addr("B"): ""},
+	},
+	{
+		Title:       "No price source",
+		Query:       Query{NumBlocks: 10, Limit: 2},
+		ExpectedUSD: map[entities.Address]string{addr("A"): "", addr("B"): ""},
+	},
+}
+
+// pricesMock is a PriceSource mock. A wei costs the block number dollars and
+// a USDT unit costs one dollar. Other assets are not priced
+type pricesMock struct {
+	// Prices are known since the moment
+	since time.Time
+}
+
+func (m pricesMock) PriceAt(_ context.Context, asset entities.Asset, at time.Time) (*entities.Price, error) {
+	if at.Before(m.since) {
+		return nil, errors.New("price not found")
+	}
+
+	switch asset.Symbol {
+	case entities.SymbolETH:
+		// Mock blocks are 12 seconds apart
+		return &entities.Price{USD: big.NewRat(at.Unix()/12, 1), Time: at}, nil
+	case "USDT":
+		return &entities.Price{USD: big.NewRat(1, 1), Decimals: 6, Time: at}, nil
+	default:
+		return nil, errors.New("unknown asset")
+	}
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/alitto/pond"
+	"github.com/optclblast/blk/internal/entities"
+)
+
+// verifyWallets compares the report wallets deltas with their on-chain balance
+// changes over the report window, i.e. balance at ToBlock minus balance at
+// FromBlock-1. Token wallets are not verified. Old windows require an archive node
+func (t *ethInteractor) verifyWallets(ctx context.Context, report *entities.DeltaReport) {
+	if len(report.Wallets) == 0 {
+		return
+	}
+
+	var (
+		before   entities.BlockNumber
+		after    = entities.NewBlockNumber(new(big.Int).SetUint64(report.Coverage.ToBlock))
+		complete = report.Coverage.Complete()
+	)
+
+	// Balances before the genesis block are zero
+	if report.Coverage.FromBlock > 0 {
+		before = entities.NewBlockNumber(new(big.Int).SetUint64(report.Coverage.FromBlock - 1))
+	}
+
+	pool := pond.New(fetchWorkersPoolSize, len(report.Wallets))
+
+	for _, w := range report.Wallets {
+		if !w.Token.IsZero() {
+			continue
+		}
+
+		pool.Submit(func() {
+			w.Verification = t.verifyWallet(ctx, w, before, after, complete)
+		})
+	}
+
+	pool.StopAndWait()
+}
+
+// verifyWallet compares the wallet delta with its balance change between
+// the before and after blocks. Empty before means zero balance
+func (t *ethInteractor) verifyWallet(
+	ctx context.Context,
+	w *entities.Wallet,
+	before, after entities.BlockNumber,
+	complete bool,
+) *entities.Verification {
+	v := new(entities.Verification)
+
+	balanceAfter, err := t.client.BalanceAt(ctx, w.Address, after)
+	if err != nil {
+		v.Error = fmt.Sprintf("error fetch balance at block %s. %s", after, err)
+
+		return v
+	}
+
+	balanceBefore := new(big.Int)
+
+	if before != "" {
+		if balanceBefore, err = t.client.BalanceAt(ctx, w.Address, before); err != nil {
+			v.Error = fmt.Sprintf("error fetch balance at block %s. %s", before, err)
+
+			return v
+		}
+	}
+
+	v.Actual = new(big.Int).Sub(balanceAfter, balanceBefore)
+	v.Discrepancy = new(big.Int).Sub(v.Actual, w.Delta)
+	v.Hints = t.discrepancyHints(v.Discrepancy, complete)
+
+	return v
+}
+
+// discrepancyHints returns possible causes of the discrepancy
+// given the accounting mode
+func (t *ethInteractor) discrepancyHints(discrepancy *big.Int, complete bool) []entities.VerificationHint {
+	if discrepancy.Sign() == 0 {
+		return nil
+	}
+
+	var hints []entities.VerificationHint
+
+	if !complete {
+		hints = append(hints, entities.HintMissingBlocks)
+	}
+
+	if !t.accounting.Has(AccountingTraces) {
+		hints = append(hints, entities.HintInternalTransfers)
+	}
+
+	// The wallet lost more than computed
+	if discrepancy.Sign() < 0 {
+		if !t.accounting.Has(AccountingFees) {
+			hints = append(hints, entities.HintFees)
+		}
+
+		return hints
+	}
+
+	// The wallet gained more than computed
+	if !t.accounting.Has(AccountingFees) && !t.accounting.Has(AccountingRewards) {
+		hints = append(hints, entities.HintRewards)
+	}
+
+	if !t.accounting.Has(AccountingWithdrawals) {
+		hints = append(hints, entities.HintWithdrawals)
+	}
+
+	return hints
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+func TestVerifyWallets(t *testing.T) {
+	for _, tc := range verifyTests {
+		t.Run(tc.Title, func(t *testing.T) {
+			client := newNodeClientMock(30, true)
+
+			for _, num := range tc.MissingBlocks {
+				delete(client.blocks, entities.NewBlockNumber(big.NewInt(num)))
+			}
+
+			ethInteractor := NewEthInteractor(slog.Default(), client)
+
+			report, err := ethInteractor.TopChangedAddresses(
+				context.TODO(),
+				Query{NumBlocks: 10, Limit: 2, Verify: true},
+			)
+			if err != nil {
+				t.Fatalf("error: %s\n", err.Error())
+			}
+
+			for _, w := range report.Wallets {
+				v := w.Verification
+				if v == nil || v.Error != "" {
+					t.Fatalf("wallet %s is not verified: %+v", w.Address, v)
+				}
+
+				if v.Discrepancy.Int64() != tc.ExpectedDiscrepancy[w.Address] {
+					t.Fatalf(
+						"invalid discrepancy of %s: %s | Expected: %d",
+						w.Address, v.Discrepancy, tc.ExpectedDiscrepancy[w.Address],
+					)
+				}
+
+				if !slices.Equal(v.Hints, tc.ExpectedHints[w.Address]) {
+					t.Fatalf("invalid hints of %s: %v | Expected: %v", w.Address, v.Hints, tc.ExpectedHints[w.Address])
+				}
+			}
+		})
+	}
+}
+
+type VerifyTestCase struct {
+	Title               string
+	MissingBlocks       []int64
+	ExpectedDiscrepancy map[entities.Address]int64
+	ExpectedHints       map[entities.Address][]entities.VerificationHint
+}
+
+var verifyTests = []VerifyTestCase{
+	{
+		Title: "Complete window",
+	},
+	{
+		Title:         "Block 27 is missing",
+		MissingBlocks: []int64{27},
+		ExpectedDiscrepancy: map[entities.Address]int64{
+			addr("A"): -28,
+			addr("B"): 28,
+		},
+		ExpectedHints: map[entities.Address][]entities.VerificationHint{
+			addr("A"): {
+				entities.HintMissingBlocks,
+				entities.HintInternalTransfers,
+				entities.HintFees,
+			},
+			addr("B"): {
+				entities.HintMissingBlocks,
+				entities.HintInternalTransfers,
+				entities.HintRewards,
+				entities.HintWithdrawals,
+			},
+		},
+	},
+}
@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/optclblast/blk/internal/entities"
+)
+
+// resolveWindow resolves the query into an inclusive [from, to] blocks window.
+// head is the current head block number
+func (t *ethInteractor) resolveWindow(
+	ctx context.Context,
+	q Query,
+	head uint64,
+) (uint64, uint64, error) {
+	if q.FromBlock != "" && !q.FromTime.IsZero() || q.ToBlock != "" && !q.ToTime.IsZero() {
+		return 0, 0, fmt.Errorf("error block and time bounds are mutually exclusive. %w", ErrorInvalidRange)
+	}
+
+	var (
+		to  = head
+		err error
+	)
+
+	switch {
+	case q.ToBlock != "":
+		to, err = t.resolveBlockRef(ctx, q.ToBlock, head)
+	case !q.ToTime.IsZero():
+		to, err = t.lastBlockAtOrBefore(ctx, q.ToTime, head)
+	}
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("error resolve window upper bound. %w", err)
+	}
+
+	var from uint64
+
+	switch {
+	case q.FromBlock != "":
+		from, err = t.resolveBlockRef(ctx, q.FromBlock, head)
+	case !q.FromTime.IsZero():
+		from, err = t.firstBlockAtOrAfter(ctx, q.FromTime, head)
+	default:
+		from = to + 1 - min(uint64(q.NumBlocks), to+1)
+	}
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("error resolve window lower bound. %w", err)
+	}
+
+	switch {
+	case to > head:
+		return 0, 0, fmt.Errorf("error block %d is ahead of the head block %d. %w", to, head, ErrorInvalidRange)
+	case from > to:
+		return 0, 0, fmt.Errorf("error empty window [%d, %d]. %w", from, to, ErrorInvalidRange)
+	case to-from+1 > uint64(t.maxWindow):
+		return 0, 0, fmt.Errorf(
+			"error window of %d blocks exceeds the limit of %d blocks. %w",
+			to-from+1, t.maxWindow, ErrorInvalidRange,
+		)
+	}
+
+	return from, to, nil
+}
+
+// resolveBlockRef resolves a block number in decimal or hex, or a block tag
+func (t *ethInteractor) resolveBlockRef(
+	ctx context.Context,
+	ref string,
+	head uint64,
+) (uint64, error) {
+	switch tag := entities.BlockNumber(ref); tag {
+	case entities.BlockTagLatest:
+		return head, nil
+	case entities.BlockTagEarliest:
+		return 0, nil
+	case entities.BlockTagSafe, entities.BlockTagFinalized:
+		block, err := t.client.BlockHeaderByNumber(ctx, tag)
+		if err != nil {
+			return 0, fmt.Errorf("error fetch %s block. %w", tag, err)
+		}
+
+		return block.Number.Uint64(), nil
+	}
+
+	var (
+		num uint64
+		err error
+	)
+
+	if hex, ok := strings.CutPrefix(ref, "0x"); ok {
+		num, err = strconv.ParseUint(hex, 16, 64)
+	} else {
+		num, err = strconv.ParseUint(ref, 10, 64)
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("error invalid block reference %q. %w", ref, ErrorInvalidRange)
+	}
+
+	return num, nil
+}
+
+// firstBlockAtOrAfter returns the number of the first block
+// with timestamp not earlier than ts
+func (t *ethInteractor) firstBlockAtOrAfter(
+	ctx context.Context,
+	ts time.Time,
+	head uint64,
+) (uint64, error) {
+	num, err := t.searchBlock(ctx, head, func(b *entities.Block) bool {
+		return !b.Timestamp.Before(ts)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if num > head {
+		return 0, fmt.Errorf("error no blocks after %s. %w", ts, ErrorInvalidRange)
+	}
+
+	return num, nil
+}
+
+// lastBlockAtOrBefore returns the number of the last block
+// with timestamp not later than ts
+func (t *ethInteractor) lastBlockAtOrBefore(
+	ctx context.Context,
+	ts time.Time,
+	head uint64,
+) (uint64, error) {
+	num, err := t.searchBlock(ctx, head, func(b *entities.Block) bool {
+		return b.Timestamp.After(ts)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if num == 0 {
+		return 0, fmt.Errorf("error no blocks before %s. %w", ts, ErrorInvalidRange)
+	}
+
+	return num - 1, nil
+}
+
+// searchBlock uses binary search to find the smallest block number in [0, head]
+// for which pred is true. pred must be monotonic over the blocks timestamps.
+// If pred is false for every block, head + 1 is returned
+func (t *ethInteractor) searchBlock(
+	ctx context.Context,
+	head uint64,
+	pred func(b *entities.Block) bool,
+) (uint64, error) {
+	lo, hi := uint64(0), head+1
+
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		block, err := t.client.BlockHeaderByNumber(ctx, entities.NewBlockNumber(new(big.Int).SetUint64(mid)))
+		if err != nil {
+			return 0, fmt.Errorf("error fetch block %d header. %w", mid, err)
+		}
+
+		if pred(block) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	return lo, nil
+}
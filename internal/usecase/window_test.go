@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestResolveWindow(t *testing.T) {
+	// Blocks [0, 99], block N is produced at N * 12 seconds
+	client := newNodeClientMock(100, true)
+
+	ethInteractor := &ethInteractor{
+		log:       slog.Default(),
+		client:    client,
+		maxWindow: 50,
+	}
+
+	for _, tc := range windowTests {
+		t.Run(tc.Title, func(t *testing.T) {
+			from, to, err := ethInteractor.resolveWindow(context.TODO(), tc.Query, 99)
+
+			if tc.MustFail {
+				if !errors.Is(err, ErrorInvalidRange) {
+					t.Fatalf("invalid error: %v | Expected: %v", err, ErrorInvalidRange)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error: %s\n", err.Error())
+			}
+
+			if from != tc.ExpectedFrom || to != tc.ExpectedTo {
+				t.Fatalf("invalid window: [%d, %d] | Expected: [%d, %d]", from, to, tc.ExpectedFrom, tc.ExpectedTo)
+			}
+		})
+	}
+}
+
+type WindowTestCase struct {
+	Title        string
+	Query        Query
+	ExpectedFrom uint64
+	ExpectedTo   uint64
+	MustFail     bool
+}
+
+var windowTests = []WindowTestCase{
+	{
+		Title:        "Last N blocks",
+		Query:        Query{NumBlocks: 10},
+		ExpectedFrom: 90,
+		ExpectedTo:   99,
+	},
+	{
+		Title:        "More blocks than the chain has",
+		Query:        Query{NumBlocks: 10, ToBlock: "5"},
+		ExpectedFrom: 0,
+		ExpectedTo:   5,
+	},
+	{
+		Title:        "Decimal and hex bounds",
+		Query:        Query{FromBlock: "10", ToBlock: "0x14"},
+		ExpectedFrom: 10,
+		ExpectedTo:   20,
+	},
+	{
+		Title:        "N blocks before a tag",
+		Query:        Query{NumBlocks: 5, ToBlock: "finalized"},
+		ExpectedFrom: 93,
+		ExpectedTo:   97,
+	},
+	{
+		Title:        "From a block to latest",
+		Query:        Query{FromBlock: "80", ToBlock: "latest"},
+		ExpectedFrom: 80,
+		ExpectedTo:   99,
+	},
+	{
+		Title: "Time range",
+		Query: Query{
+			// Between blocks 10 and 11
+			FromTime: time.Unix(125, 0),
+			// Exactly block 20
+			ToTime: time.Unix(240, 0),
+		},
+		ExpectedFrom: 11,
+		ExpectedTo:   20,
+	},
+	{
+		Title:    "Time range ahead of the head",
+		Query:    Query{FromTime: time.Unix(100*12, 0)},
+		MustFail: true,
+	},
+	{
+		Title:    "Window is too large",
+		Query:    Query{FromBlock: "0", ToBlock: "50"},
+		MustFail: true,
+	},
+	{
+		Title:    "Reversed bounds",
+		Query:    Query{FromBlock: "20", ToBlock: "10"},
+		MustFail: true,
+	},
+	{
+		Title:    "Block and time bounds are mixed",
+		Query:    Query{FromBlock: "20", FromTime: time.Unix(125, 0)},
+		MustFail: true,
+	},
+	{
+		Title:    "Invalid block reference",
+		Query:    Query{FromBlock: "pending"},
+		MustFail: true,
+	},
+}